@@ -0,0 +1,87 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/aybabtme/bomberman/bench"
+	"github.com/aybabtme/bomberman/board"
+	"github.com/aybabtme/bomberman/engine"
+	"github.com/aybabtme/bomberman/match"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// runBench plays the players of a roster against each other many times,
+// without a terminal, and prints how often each side wins.
+func runBench(args []string) {
+	flags := flag.NewFlagSet("bench", flag.ExitOnError)
+	logOpts := logFlags(flags, "warn")
+	rosterFile := flags.String("roster", "", "JSON file describing the players and teams of the matches")
+	mapFile := flags.String("map", "", "text file describing the arena, instead of generated ones")
+	generator := flags.String("arena", "classic", "how to generate arenas: "+strings.Join(board.Generators, ", "))
+	matches := flags.Int("matches", 1000, "how many matches to play")
+	workers := flags.Int("workers", runtime.NumCPU(), "how many matches to play at once")
+	maxTurns := flags.Int("max-turns", 3000, "turns after which a match is a draw")
+	seed := flags.Int64("seed", 0, "seed of the first match, random if zero")
+	rotate := flags.Bool("rotate", true, "move players to the next spawn every match")
+	ratings := flags.String("ratings", "", "file holding the rating history, to rate every match")
+	system := flags.String("rating-system", "elo", "how players are rated: elo or trueskill")
+	flags.Parse(args)
+	logOpts.open()
+
+	if *rosterFile == "" {
+		log.Fatalf("bench needs a roster of AI players")
+	}
+	roster, err := loadRoster(*rosterFile)
+	if err != nil {
+		log.Fatalf("Loading roster: %v", err)
+	}
+	dist, err := powerUpDistribution(roster)
+	if err != nil {
+		log.Fatalf("Reading power-ups of roster: %v", err)
+	}
+	arena, err := loadMap(*mapFile)
+	if err != nil {
+		log.Fatalf("Loading map: %v", err)
+	}
+
+	cfg := match.Config{
+		Arena:          *generator,
+		Map:            arena,
+		Width:          MaxX + 2,
+		Height:         MaxY + 2,
+		RockFreeRadius: RockFreeArea,
+		RockDensity:    RockDensity,
+		PowerUps:       dist,
+		Rules:          engine.DefaultRules,
+		FriendlyFire:   roster.FriendlyFire,
+		MaxTurns:       *maxTurns,
+	}
+	cfg.Vision.Radius = roster.VisionRadius
+	cfg.Vision.LineOfSight = roster.LineOfSight
+	logOpts.crashDumps(&cfg)
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+	store, err := openRatings(*ratings, *system)
+	if err != nil {
+		log.Fatalf("Opening ratings: %v", err)
+	}
+	if store != nil {
+		defer store.Close()
+	}
+
+	log.Infof("Benchmarking %d matches on %d workers, from seed %d.", *matches, *workers, *seed)
+	rep, err := bench.Run(cfg, roster.Players, bench.Options{
+		Matches:     *matches,
+		Workers:     *workers,
+		Seed:        *seed,
+		RotateSeats: *rotate,
+		Ratings:     store,
+	}, log)
+	if err != nil {
+		log.Fatalf("Benchmarking: %v", err)
+	}
+	fmt.Print(rep)
+}
@@ -0,0 +1,245 @@
+// Package bench plays many headless matches between the same players and
+// tells how often each side wins, to know if a change to a bot is an
+// improvement or noise.
+package bench
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/aybabtme/bomberman/config"
+	"github.com/aybabtme/bomberman/logger"
+	"github.com/aybabtme/bomberman/match"
+	"github.com/aybabtme/bomberman/rating"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// z is the quantile of the normal distribution for 95% confidence.
+const z = 1.96
+
+// Options says how many matches to play and how.
+type Options struct {
+	Matches int
+	// Workers play matches in parallel.
+	Workers int
+	// Seed of the first match; the others follow.
+	Seed int64
+	// RotateSeats moves every player to the next spawn on each match, so
+	// no one keeps the best spot.
+	RotateSeats bool
+	// Ratings, if set, rates the players of every match.
+	Ratings *rating.Store
+}
+
+// Interval is an estimate with its 95% confidence interval.
+type Interval struct {
+	Value, Low, High float64
+}
+
+func (i Interval) String() string {
+	return fmt.Sprintf("%.3f [%.3f, %.3f]", i.Value, i.Low, i.High)
+}
+
+// Side is how a team, or a player on their own, fared.
+type Side struct {
+	Name    string
+	Wins    int
+	WinRate Interval
+}
+
+// Report sums up the matches played.
+type Report struct {
+	Matches int
+	Sides   []Side
+	Draws   int
+	// Truncated draws hit the turn limit.
+	Truncated int
+	DrawRate  Interval
+	// Turns is the average length of a match.
+	Turns   Interval
+	Elapsed time.Duration
+}
+
+// Run plays the matches and reports on them. It stops at the first match that
+// fails to start.
+func Run(cfg match.Config, seats []config.Entry, opts Options, log *logger.Logger) (*Report, error) {
+	if opts.Workers < 1 {
+		opts.Workers = 1
+	}
+	start := time.Now()
+
+	jobs := make(chan int)
+	results := make(chan *match.Result)
+	errs := make(chan error, opts.Workers)
+	wg := sync.WaitGroup{}
+	for w := 0; w < opts.Workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				res, err := play(cfg, seated(seats, i, opts.RotateSeats), opts.Seed+int64(i), opts.Ratings, log)
+				if err != nil {
+					errs <- err
+					return
+				}
+				results <- res
+			}
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+		for i := 0; i < opts.Matches; i++ {
+			select {
+			case jobs <- i:
+			case err := <-errs:
+				// Let the caller see it once the workers are done.
+				errs <- err
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	all := []*match.Result{}
+	for res := range results {
+		all = append(all, res)
+	}
+	select {
+	case err := <-errs:
+		return nil, err
+	default:
+	}
+
+	rep := summarize(seats, all)
+	rep.Elapsed = time.Since(start)
+	return rep, nil
+}
+
+// play plays a match, and rates it if asked to. Matches are told apart by
+// their seed and seats, since the same ones play the same match.
+func play(cfg match.Config, seats []config.Entry, seed int64, ratings *rating.Store, log *logger.Logger) (*match.Result, error) {
+	res, err := match.Play(cfg, seats, seed, log)
+	if err != nil || ratings == nil {
+		return res, err
+	}
+	names := make([]string, len(seats))
+	for i, e := range seats {
+		names[i] = e.Name
+	}
+	_, err = ratings.Record(rating.Match{
+		ID:      fmt.Sprintf("bench/%d/%s", seed, strings.Join(names, ",")),
+		Players: names,
+		Ranks:   res.Ranks(names),
+	})
+	return res, err
+}
+
+// seated rotates the seats of the i-th match.
+func seated(seats []config.Entry, i int, rotate bool) []config.Entry {
+	if !rotate {
+		return seats
+	}
+	n := i % len(seats)
+	return append(append([]config.Entry{}, seats[n:]...), seats[:n]...)
+}
+
+func summarize(seats []config.Entry, results []*match.Result) *Report {
+	rep := &Report{Matches: len(results)}
+
+	wins := make(map[string]int)
+	var turns []float64
+	for _, res := range results {
+		if res.Draw() {
+			rep.Draws++
+			if res.Truncated {
+				rep.Truncated++
+			}
+		} else {
+			wins[res.Winner]++
+		}
+		turns = append(turns, float64(res.Turns))
+	}
+
+	seen := make(map[string]bool)
+	for _, e := range seats {
+		name := e.Team
+		if name == "" {
+			name = e.Name
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		rep.Sides = append(rep.Sides, Side{
+			Name:    name,
+			Wins:    wins[name],
+			WinRate: Wilson(wins[name], len(results)),
+		})
+	}
+	sort.SliceStable(rep.Sides, func(i, j int) bool { return rep.Sides[i].Wins > rep.Sides[j].Wins })
+
+	rep.DrawRate = Wilson(rep.Draws, len(results))
+	rep.Turns = Mean(turns)
+	return rep
+}
+
+// Wilson estimates a rate from successes out of n trials, with the Wilson
+// score interval, which behaves for rates close to 0 or 1.
+func Wilson(successes, n int) Interval {
+	if n == 0 {
+		return Interval{}
+	}
+	p := float64(successes) / float64(n)
+	nf := float64(n)
+	denom := 1 + z*z/nf
+	center := (p + z*z/(2*nf)) / denom
+	spread := z * math.Sqrt(p*(1-p)/nf+z*z/(4*nf*nf)) / denom
+	return Interval{Value: p, Low: math.Max(0, center-spread), High: math.Min(1, center+spread)}
+}
+
+// Mean estimates the mean of samples, with a normal confidence interval.
+func Mean(samples []float64) Interval {
+	n := float64(len(samples))
+	if n == 0 {
+		return Interval{}
+	}
+	var sum float64
+	for _, s := range samples {
+		sum += s
+	}
+	mean := sum / n
+	if n < 2 {
+		return Interval{mean, mean, mean}
+	}
+	var sq float64
+	for _, s := range samples {
+		sq += (s - mean) * (s - mean)
+	}
+	spread := z * math.Sqrt(sq/(n-1)) / math.Sqrt(n)
+	return Interval{mean, mean - spread, mean + spread}
+}
+
+// String formats the report as a table.
+func (r *Report) String() string {
+	buf := bytes.NewBuffer(nil)
+	fmt.Fprintf(buf, "%d matches in %v (%.0f matches/s)\n\n", r.Matches, r.Elapsed,
+		float64(r.Matches)/r.Elapsed.Seconds())
+	tw := tabwriter.NewWriter(buf, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "side\twins\twin rate [95% CI]")
+	for _, s := range r.Sides {
+		fmt.Fprintf(tw, "%s\t%d\t%v\n", s.Name, s.Wins, s.WinRate)
+	}
+	fmt.Fprintf(tw, "draws\t%d\t%v\n", r.Draws, r.DrawRate)
+	tw.Flush()
+	fmt.Fprintf(buf, "\n%d draws hit the turn limit\n", r.Truncated)
+	fmt.Fprintf(buf, "turns per match: %v\n", r.Turns)
+	return buf.String()
+}
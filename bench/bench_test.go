@@ -0,0 +1,53 @@
+package bench_test
+
+import (
+	"github.com/aybabtme/bomberman/bench"
+	"github.com/aybabtme/bomberman/config"
+	"github.com/aybabtme/bomberman/engine"
+	"github.com/aybabtme/bomberman/logger"
+	"github.com/aybabtme/bomberman/match"
+	"io/ioutil"
+	"testing"
+)
+
+func TestWilson(t *testing.T) {
+	i := bench.Wilson(50, 100)
+	if i.Value != 0.5 || i.Low < 0.40 || i.Low > 0.41 || i.High < 0.59 || i.High > 0.60 {
+		t.Errorf("want 0.5 [0.40, 0.60], got %v", i)
+	}
+	if i := bench.Wilson(0, 10); i.Low != 0 || i.High <= 0 {
+		t.Errorf("want a positive upper bound without successes, got %v", i)
+	}
+}
+
+func TestRunCountsDraws(t *testing.T) {
+	cfg := match.Config{
+		Arena:          "classic",
+		Width:          13,
+		Height:         11,
+		RockFreeRadius: 1,
+		RockDensity:    0.5,
+		Rules:          engine.DefaultRules,
+		MaxTurns:       20,
+	}
+	seats := []config.Entry{
+		{Name: "p1", Kind: "immobile"},
+		{Name: "p2", Kind: "immobile"},
+	}
+	log := logger.NewWriter("", ioutil.Discard, logger.Error)
+	rep, err := bench.Run(cfg, seats, bench.Options{Matches: 30, Workers: 4, RotateSeats: true}, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rep.Matches != 30 || rep.Draws != 30 || rep.Truncated != 30 {
+		t.Errorf("want 30 truncated draws, got %+v", rep)
+	}
+	if rep.Turns.Value != 20 {
+		t.Errorf("want matches to last 20 turns, got %v", rep.Turns)
+	}
+
+	seats[0].Kind = "local"
+	if _, err := bench.Run(cfg, seats, bench.Options{Matches: 30, Workers: 4}, log); err == nil {
+		t.Errorf("want local players to be refused")
+	}
+}
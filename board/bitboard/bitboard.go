@@ -0,0 +1,323 @@
+// Package bitboard is a compact board for simulations. Walls, rocks and
+// flames are bitsets, power-ups and timers are flat arrays indexed by cell,
+// so copying a board is a handful of small memcpys. It can be converted from
+// and to the boards players receive, and stepped turn by turn following the
+// same rules and timings as the engine.
+//
+// A Board is a board.Grid, like the boards of the engine: code written against
+// that API runs unchanged on simulated boards.
+package bitboard
+
+import (
+	"github.com/aybabtme/bomberman/board"
+	"github.com/aybabtme/bomberman/cell"
+	"github.com/aybabtme/bomberman/game"
+	"github.com/aybabtme/bomberman/objects"
+	"github.com/aybabtme/bomberman/player"
+	"github.com/aybabtme/bomberman/powerup"
+)
+
+// Rules are the timings and defaults of the game being simulated.
+type Rules struct {
+	TurnsToExplode   int
+	TurnsToFlamout   int
+	TurnsToReplenish int
+	FriendlyFire     bool
+	// Stats of players only seen on the board.
+	DefaultMaxBomb, DefaultRadius int
+}
+
+// Board is a compact board. Cells are indexed by x*Height+y.
+type Board struct {
+	Width, Height int
+	Rules         Rules
+	Turn          int
+
+	Walls, Rocks, Flames Bitset
+	// Teleporters send players to the next one, like on the boards of the
+	// engine.
+	Teleporters Bitset
+	// FlameTimers are the turns left before flames clear, by cell.
+	FlameTimers []uint8
+	// PowerUps lying on the ground, by cell: 0 for none, else the index of
+	// the power-up in powerup.Catalogue plus one.
+	PowerUps []uint8
+
+	Bombs   []Bomb
+	Players []Player
+	// replenish are bombs given back to their owner when their timer is up.
+	replenish []timer
+}
+
+// Bomb is a bomb ticking on the board.
+type Bomb struct {
+	Cell   int
+	Owner  int
+	Radius int
+	Timer  int
+	Pierce bool
+	// Sliding bombs move by (DX, DY) every turn.
+	DX, DY int
+	// placed bombs start ticking on the next turn.
+	placed bool
+}
+
+// Player is the state of a player, indexed like Board.Players.
+type Player struct {
+	Name, Team                 string
+	Cell                       int
+	Alive                      bool
+	Bombs, MaxBomb, Radius     int
+	Speed, Shield, CursedTurns int
+	CanKick, CanRemote, Pierce bool
+}
+
+type timer struct {
+	owner, turns int
+}
+
+var _ board.Grid = (*Board)(nil)
+
+// New creates an empty board, enclosed by walls.
+func New(width, height int, rules Rules) *Board {
+	cells := width * height
+	b := &Board{
+		Width:       width,
+		Height:      height,
+		Rules:       rules,
+		Walls:       newBitset(cells),
+		Rocks:       newBitset(cells),
+		Flames:      newBitset(cells),
+		Teleporters: newBitset(cells),
+		FlameTimers: make([]uint8, cells),
+		PowerUps:    make([]uint8, cells),
+	}
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			if x == 0 || x == width-1 || y == 0 || y == height-1 {
+				b.Walls.Set(b.Index(x, y))
+			}
+		}
+	}
+	return b
+}
+
+// Index is the index of the cell at (x, y).
+func (b *Board) Index(x, y int) int {
+	return x*b.Height + y
+}
+
+// XY is the position of the cell at index i.
+func (b *Board) XY(i int) (int, int) {
+	return i / b.Height, i % b.Height
+}
+
+// Copy makes an independent copy of the board.
+func (b *Board) Copy() *Board {
+	c := *b
+	c.Walls = append(Bitset(nil), b.Walls...)
+	c.Rocks = append(Bitset(nil), b.Rocks...)
+	c.Flames = append(Bitset(nil), b.Flames...)
+	c.Teleporters = append(Bitset(nil), b.Teleporters...)
+	c.FlameTimers = append([]uint8(nil), b.FlameTimers...)
+	c.PowerUps = append([]uint8(nil), b.PowerUps...)
+	c.Bombs = append([]Bomb(nil), b.Bombs...)
+	c.Players = append([]Player(nil), b.Players...)
+	c.replenish = append([]timer(nil), b.replenish...)
+	return &c
+}
+
+// Traversable tells if a player can walk on the cell at (x, y).
+func (b *Board) Traversable(x, y int) bool {
+	i := b.Index(x, y)
+	return !b.Walls.Has(i) && !b.Rocks.Has(i) && b.bombAt(i) < 0
+}
+
+// PlayerByName finds the index of a player, or -1.
+func (b *Board) PlayerByName(name string) int {
+	for i := range b.Players {
+		if b.Players[i].Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func (b *Board) bombAt(i int) int {
+	for n := range b.Bombs {
+		if b.Bombs[n].Cell == i {
+			return n
+		}
+	}
+	return -1
+}
+
+func (b *Board) playerAt(i int) int {
+	for n := range b.Players {
+		if b.Players[n].Alive && b.Players[n].Cell == i {
+			return n
+		}
+	}
+	return -1
+}
+
+// FromExported converts the board a player sees. Players in known are taken
+// as is, and the players only seen on the board get the default stats of the
+// rules. Unknown cells are taken as ground.
+func FromExported(view [][]*cell.Exported, known []player.State, now int, rules Rules) *Board {
+	b := New(len(view), len(view[0]), rules)
+	b.Turn = now
+
+	for _, s := range known {
+		b.Players = append(b.Players, Player{
+			Name: s.Name, Team: s.Team,
+			Cell: b.Index(s.X, s.Y), Alive: s.Alive,
+			Bombs: s.Bombs, MaxBomb: s.MaxBomb, Radius: s.MaxRadius,
+			Speed: s.Speed, Shield: s.Shield, CursedTurns: s.CursedTurns,
+			CanKick: s.CanKick, CanRemote: s.CanRemote, Pierce: s.Pierce,
+		})
+	}
+
+	for x := range view {
+		for y, e := range view[x] {
+			i := b.Index(x, y)
+			for _, l := range e.Layers {
+				switch l.Kind {
+				case cell.Wall:
+					b.Walls.Set(i)
+				case cell.Rock:
+					b.Rocks.Set(i)
+				case cell.Flame:
+					b.Flames.Set(i)
+					// A flame seen on its last turn clears on the next.
+					b.FlameTimers[i] = uint8(clamp(l.TurnsLeft, 1, 255))
+				case cell.Teleporter:
+					b.Teleporters.Set(i)
+				case cell.PowerUp:
+					b.PowerUps[i] = powerUpIndex(powerup.Kind(l.PowerUp))
+				case cell.Bomb:
+					b.Bombs = append(b.Bombs, Bomb{
+						Cell:   i,
+						Owner:  b.player(l.Owner, -1),
+						Radius: l.Radius,
+						Timer:  l.TurnsLeft,
+					})
+				case cell.Player:
+					b.player(l.Name, i)
+				}
+			}
+		}
+	}
+	return b
+}
+
+// player finds a player by name, adding it with default stats at cell i if
+// it's not known yet.
+func (b *Board) player(name string, i int) int {
+	if n := b.PlayerByName(name); n >= 0 {
+		if i >= 0 {
+			b.Players[n].Cell, b.Players[n].Alive = i, true
+		}
+		return n
+	}
+	b.Players = append(b.Players, Player{
+		Name:    name,
+		Cell:    i,
+		Alive:   i >= 0,
+		MaxBomb: b.Rules.DefaultMaxBomb,
+		Radius:  b.Rules.DefaultRadius,
+		Speed:   1,
+	})
+	return len(b.Players) - 1
+}
+
+func powerUpIndex(k powerup.Kind) uint8 {
+	for i, pu := range powerup.Catalogue {
+		if pu.Kind == k {
+			return uint8(i + 1)
+		}
+	}
+	return 0
+}
+
+// clamp keeps v between lo and hi.
+func clamp(v, lo, hi int) int {
+	switch {
+	case v < lo:
+		return lo
+	case v > hi:
+		return hi
+	}
+	return v
+}
+
+// Clone converts the board back into what players see, with the objects of
+// the objects package. Timers count turns left, so now doesn't change them.
+func (b *Board) Clone(now int) [][]*cell.Exported {
+	view := make([][]*cell.Exported, b.Width)
+	for x := range view {
+		view[x] = make([]*cell.Exported, b.Height)
+		for y := range view[x] {
+			c := cell.NewCell(objects.Ground, x, y)
+			i := b.Index(x, y)
+			if b.Walls.Has(i) {
+				c.Push(objects.Wall)
+			}
+			if b.Teleporters.Has(i) {
+				c.Push(objects.Teleporter)
+			}
+			if pu := b.PowerUps[i]; pu != 0 {
+				c.Push(powerup.Catalogue[pu-1].Object)
+			}
+			if b.Rocks.Has(i) {
+				c.Push(objects.Rock)
+			}
+			if n := b.playerAt(i); n >= 0 {
+				c.Push(&objects.TboxPlayer{Name: b.Players[n].Name})
+			}
+			if n := b.bombAt(i); n >= 0 {
+				c.Push(&timed{objects.Bomb, b.Players[b.Bombs[n].Owner].Name, b.Bombs[n].Radius, b.Bombs[n].Timer})
+			}
+			if b.Flames.Has(i) {
+				c.Push(&timed{objects.Flame, "", 0, int(b.FlameTimers[i])})
+			}
+			view[x][y] = c.Export(now)
+		}
+	}
+	return view
+}
+
+// CloneFor converts the board as seen by viewers, like board.Board does. Cells
+// none of them can see are unknown. Without viewers, the whole board is seen.
+func (b *Board) CloneFor(now int, v game.Vision, viewers []*player.State) [][]*cell.Exported {
+	view := b.Clone(now)
+	if !v.Limited() || len(viewers) == 0 {
+		return view
+	}
+	seen := board.Sight(b.Width, b.Height, func(x, y int) bool {
+		i := b.Index(x, y)
+		return b.Walls.Has(i) || b.Rocks.Has(i)
+	}, v, viewers)
+	for x := range view {
+		for y := range view[x] {
+			if !seen[x][y] {
+				view[x][y] = cell.ExportUnknown()
+			}
+		}
+	}
+	return view
+}
+
+// timed describes bombs and flames when exporting.
+type timed struct {
+	cell.GameObject
+	owner     string
+	radius    int
+	turnsLeft int
+}
+
+func (t *timed) Describe(l *cell.Layer, now int) {
+	l.Owner = t.owner
+	l.Radius = t.radius
+	l.TurnsLeft = t.turnsLeft
+}
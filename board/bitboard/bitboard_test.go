@@ -0,0 +1,232 @@
+package bitboard_test
+
+import (
+	"fmt"
+	"github.com/aybabtme/bomberman/board"
+	"github.com/aybabtme/bomberman/board/bitboard"
+	"github.com/aybabtme/bomberman/cell"
+	"github.com/aybabtme/bomberman/config"
+	"github.com/aybabtme/bomberman/engine"
+	"github.com/aybabtme/bomberman/game"
+	"github.com/aybabtme/bomberman/logger"
+	"github.com/aybabtme/bomberman/match"
+	"github.com/aybabtme/bomberman/objects"
+	"github.com/aybabtme/bomberman/player"
+	"github.com/aybabtme/bomberman/powerup"
+	"io/ioutil"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+)
+
+var rules = bitboard.Rules{
+	TurnsToExplode:   10,
+	TurnsToFlamout:   3,
+	TurnsToReplenish: 12,
+	DefaultMaxBomb:   3,
+	DefaultRadius:    3,
+}
+
+func setup(t testing.TB, arena string) (board.Board, []player.State) {
+	m, err := board.LoadMap(strings.NewReader(arena))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return spawn(m, 0.5, rand.New(rand.NewSource(1)))
+}
+
+func generated(t testing.TB) (board.Board, []player.State) {
+	gen, _ := board.NewGenerator("classic", 1, 0.5)
+	rng := rand.New(rand.NewSource(42))
+	m, err := gen.Generate(51, 23, rng)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return spawn(m, 0, rng)
+}
+
+// spawn sets up the board of a map with a player on each spawn.
+func spawn(m *board.Map, rockDensity float64, rng *rand.Rand) (board.Board, []player.State) {
+	g := game.NewGame(time.Second, powerup.Distribution{})
+	g.TurnTick.Stop()
+
+	g.Players = make(map[*player.State]player.Player)
+	var states []player.State
+	for i, s := range m.Spawns {
+		name := fmt.Sprintf("p%d", i+1)
+		pState := &player.State{Name: name, X: s.X, Y: s.Y, Alive: true,
+			MaxBomb: 3, MaxRadius: 3, Speed: 1,
+			GameObject: &objects.TboxPlayer{Name: name}}
+		g.Players[pState] = nil
+		states = append(states, *pState)
+	}
+	b, _ := board.SetupMapBoard(g, m, rockDensity, rng)
+	return b, states
+}
+
+func TestRoundTrip(t *testing.T) {
+	b, states := setup(t, "#######\n#1%.k2#\n#.#.#.#\n#3???4#\n#######\n")
+	view := b.Clone(0)
+	back := bitboard.FromExported(view, states, 0, rules).Clone(0)
+	for x := range view {
+		for y := range view[x] {
+			if !view[x][y].Equal(back[x][y]) {
+				t.Errorf("(%d, %d): want %+v, got %+v", x, y, view[x][y].Layers, back[x][y].Layers)
+			}
+		}
+	}
+}
+
+func TestStepExplodesBombs(t *testing.T) {
+	b, states := setup(t, "#######\n#1.%.2#\n#.#.#.#\n#######\n")
+	bb := bitboard.FromExported(b.Clone(0), states, 0, rules)
+
+	bb.Step([][]player.Move{{player.Right}})
+	bb.Step([][]player.Move{{player.PutBomb}})
+	bb.Step([][]player.Move{{player.Left}})
+	bb.Step([][]player.Move{{player.Down}})
+	for i := 0; i < rules.TurnsToExplode-2; i++ {
+		bb.Step(nil)
+	}
+
+	rock := bb.Index(3, 1)
+	if !bb.Flames.Has(rock) || !bb.Rocks.Has(rock) {
+		t.Fatalf("want the rock on fire")
+	}
+	if !bb.Players[0].Alive {
+		t.Errorf("want p1 to survive, hiding at (1, 2)")
+	}
+
+	copied := bb.Copy()
+	for i := 0; i < rules.TurnsToFlamout; i++ {
+		bb.Step(nil)
+	}
+	if bb.Flames.Has(rock) || bb.Rocks.Has(rock) {
+		t.Errorf("want the rock destroyed once the flame is out")
+	}
+	if !copied.Rocks.Has(rock) {
+		t.Errorf("want copies to be independent")
+	}
+}
+
+func TestStepTimingsMatchEngine(t *testing.T) {
+	matchEngine(t, "#########\n#1.%...2#\n#.#######\n#########\n",
+		[]player.Move{player.Right, player.PutBomb, player.Left, player.Down})
+}
+
+func TestStepTeleportsLikeEngine(t *testing.T) {
+	matchEngine(t, "########\n#1@..@2#\n########\n",
+		[]player.Move{player.Right, player.PutBomb, player.Left, player.Left, player.Left})
+}
+
+// matchEngine plays the moves of p1 in a match and on a bitboard, checking
+// that both boards stay the same for 30 steps.
+func matchEngine(t *testing.T, arena string, script []player.Move) {
+	m, err := board.LoadMap(strings.NewReader(arena))
+	if err != nil {
+		t.Fatal(err)
+	}
+	seats := []config.Entry{
+		{Name: "p1", Kind: match.External},
+		{Name: "p2", Kind: match.External},
+	}
+	mt, err := match.New(match.Config{Map: m, Rules: engine.DefaultRules, MaxTurns: 100}, seats,
+		1, logger.NewWriter("", ioutil.Discard, logger.Error))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mt.Close()
+	snap := mt.Engine.Snapshot()
+	bb := bitboard.FromExported(snap.Board, snap.Players, snap.Turn, rules)
+
+	for step := 0; step < 30; step++ {
+		var moves [][]player.Move
+		if step < len(script) {
+			mt.Move(mt.Players[0], script[step])
+			moves = [][]player.Move{{script[step]}}
+		}
+		mt.Step()
+		bb.Step(moves)
+
+		want, got := mt.Engine.Snapshot().Board, bb.Clone(0)
+		for x := range want {
+			for y := range want[x] {
+				if !sameTimings(want[x][y], got[x][y]) {
+					t.Fatalf("step %d, (%d, %d): want %+v, got %+v", step, x, y, want[x][y].Layers, got[x][y].Layers)
+				}
+			}
+		}
+		if want, got := mt.Players[0].Bombs, bb.Players[0].Bombs; want != got {
+			t.Fatalf("step %d: want p1 with %d bombs out, got %d", step, want, got)
+		}
+	}
+}
+
+// sameTimings tells if two cells hold the same objects with the same turns
+// left. Flames of the bitboard don't know who lit them.
+func sameTimings(a, b *cell.Exported) bool {
+	if len(a.Layers) != len(b.Layers) {
+		return false
+	}
+	for i := range a.Layers {
+		if a.Layers[i].Kind != b.Layers[i].Kind || a.Layers[i].TurnsLeft != b.Layers[i].TurnsLeft {
+			return false
+		}
+	}
+	return true
+}
+
+func TestStepStacksBombs(t *testing.T) {
+	b, states := setup(t, "#######\n#1...2#\n#######\n")
+	bb := bitboard.FromExported(b.Clone(0), states, 0, rules)
+
+	bb.Step([][]player.Move{{player.PutBomb}})
+	bb.Step([][]player.Move{{player.PutBomb}})
+	if len(bb.Bombs) != 2 || bb.Players[0].Bombs != 2 {
+		t.Errorf("want 2 bombs stacked under p1, like the engine does, got %d", len(bb.Bombs))
+	}
+}
+
+func TestFlameOnLastTurnClears(t *testing.T) {
+	b, states := setup(t, "#######\n#1...2#\n#######\n")
+	view := b.Clone(0)
+	view[3][1].Layers = append(view[3][1].Layers, cell.Layer{Kind: cell.Flame, TurnsLeft: 0})
+	bb := bitboard.FromExported(view, states, 0, rules)
+
+	flame := bb.Index(3, 1)
+	if !bb.Flames.Has(flame) || bb.FlameTimers[flame] != 1 {
+		t.Fatalf("want the flame to last one more turn, got %d", bb.FlameTimers[flame])
+	}
+	bb.Step(nil)
+	if bb.Flames.Has(flame) {
+		t.Errorf("want the flame out, timer at %d", bb.FlameTimers[flame])
+	}
+}
+
+func BenchmarkCopy(b *testing.B) {
+	eb, states := generated(b)
+	bb := bitboard.FromExported(eb.Clone(0), states, 0, rules)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		bb.Copy()
+	}
+}
+
+func BenchmarkStep(b *testing.B) {
+	eb, states := generated(b)
+	bb := bitboard.FromExported(eb.Clone(0), states, 0, rules)
+	moves := [][]player.Move{{player.PutBomb}, {player.Right}, {player.Down}, {player.Left}}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		bb.Copy().Step(moves)
+	}
+}
+
+func BenchmarkCloneBoard(b *testing.B) {
+	eb, _ := generated(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		eb.Clone(0)
+	}
+}
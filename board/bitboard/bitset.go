@@ -0,0 +1,36 @@
+package bitboard
+
+import (
+	"math/bits"
+)
+
+// Bitset holds one bit per cell of a board.
+type Bitset []uint64
+
+func newBitset(cells int) Bitset {
+	return make(Bitset, (cells+63)/64)
+}
+
+// Has tells if bit i is set.
+func (b Bitset) Has(i int) bool {
+	return b[i/64]&(1<<uint(i%64)) != 0
+}
+
+// Set sets bit i.
+func (b Bitset) Set(i int) {
+	b[i/64] |= 1 << uint(i%64)
+}
+
+// Clear clears bit i.
+func (b Bitset) Clear(i int) {
+	b[i/64] &^= 1 << uint(i%64)
+}
+
+// Count counts the bits set.
+func (b Bitset) Count() int {
+	n := 0
+	for _, word := range b {
+		n += bits.OnesCount64(word)
+	}
+	return n
+}
@@ -0,0 +1,245 @@
+package bitboard
+
+import (
+	"github.com/aybabtme/bomberman/board"
+	"github.com/aybabtme/bomberman/player"
+	"github.com/aybabtme/bomberman/powerup"
+)
+
+// Step plays a turn: each player makes their moves, in order, then bombs and
+// flames tick. Moves are indexed like Board.Players; players make up to
+// their speed in moves. The moves are those sent after seeing the board, which
+// the engine plays on its next turn, and bombs placed by them only start
+// ticking on the turn after, like in the engine.
+func (b *Board) Step(moves [][]player.Move) {
+	b.Turn++
+	for n := range b.Players {
+		p := &b.Players[n]
+		if !p.Alive {
+			continue
+		}
+		if p.CursedTurns > 0 {
+			p.CursedTurns--
+		}
+		if n >= len(moves) {
+			continue
+		}
+		for i, m := range moves[n] {
+			if i == p.Speed {
+				break
+			}
+			b.move(n, m)
+		}
+	}
+
+	for n := 0; n < len(b.replenish); n++ {
+		r := &b.replenish[n]
+		if r.turns--; r.turns <= 0 {
+			if b.Players[r.owner].Bombs > 0 {
+				b.Players[r.owner].Bombs--
+			}
+			b.replenish = append(b.replenish[:n], b.replenish[n+1:]...)
+			n--
+		}
+	}
+
+	for i := range b.FlameTimers {
+		if !b.Flames.Has(i) {
+			continue
+		}
+		if b.FlameTimers[i]--; b.FlameTimers[i] == 0 {
+			b.Flames.Clear(i)
+			b.Rocks.Clear(i)
+		}
+	}
+
+	for n := 0; n < len(b.Bombs); n++ {
+		bomb := &b.Bombs[n]
+		if bomb.DX != 0 || bomb.DY != 0 {
+			next := bomb.Cell + bomb.DX*b.Height + bomb.DY
+			if b.empty(next) {
+				bomb.Cell = next
+			} else {
+				bomb.DX, bomb.DY = 0, 0
+			}
+		}
+		if bomb.placed {
+			bomb.placed = false
+			continue
+		}
+		if bomb.Timer--; bomb.Timer <= 0 {
+			b.explode(n)
+			n--
+		}
+	}
+}
+
+func (b *Board) move(n int, m player.Move) {
+	p := &b.Players[n]
+	dx, dy := 0, 0
+	switch m {
+	case player.Up:
+		dy--
+	case player.Down:
+		dy++
+	case player.Left:
+		dx--
+	case player.Right:
+		dx++
+	case player.PutBomb:
+		// Like the engine, bombs can be stacked on a cell.
+		if p.Bombs < p.MaxBomb {
+			p.Bombs++
+			b.Bombs = append(b.Bombs, Bomb{
+				Cell:   p.Cell,
+				Owner:  n,
+				Radius: p.Radius,
+				Timer:  b.Rules.TurnsToExplode,
+				Pierce: p.Pierce,
+				placed: true,
+			})
+		}
+		return
+	case player.Detonate:
+		if !p.CanRemote {
+			return
+		}
+		for i := 0; i < len(b.Bombs); i++ {
+			if b.Bombs[i].Owner == n {
+				b.explode(i)
+				i--
+			}
+		}
+		return
+	default:
+		return
+	}
+
+	if p.CursedTurns > 0 {
+		dx, dy = -dx, -dy
+	}
+	next := p.Cell + dx*b.Height + dy
+
+	if bomb := b.bombAt(next); bomb >= 0 && p.CanKick {
+		b.Bombs[bomb].DX, b.Bombs[bomb].DY = dx, dy
+		return
+	}
+	if b.Walls.Has(next) || b.Rocks.Has(next) || b.bombAt(next) >= 0 {
+		return
+	}
+	if b.Flames.Has(next) && !b.shielded(n) {
+		p.Alive = false
+		return
+	}
+
+	p.Cell = next
+	if pu := b.PowerUps[next]; pu != 0 {
+		b.PowerUps[next] = 0
+		b.applyPowerUp(n, int(pu-1))
+	}
+	if b.Teleporters.Has(next) {
+		x, y := b.XY(next)
+		tx, ty := board.NextTeleporter(b.Width, b.Height, x, y, func(x, y int) bool {
+			return b.Teleporters.Has(b.Index(x, y))
+		})
+		if to := b.Index(tx, ty); !b.Flames.Has(to) && b.bombAt(to) < 0 && b.playerAt(to) < 0 {
+			p.Cell = to
+		}
+	}
+}
+
+// empty tells if a sliding bomb can move into a cell.
+func (b *Board) empty(i int) bool {
+	return !b.Walls.Has(i) && !b.Rocks.Has(i) && !b.Flames.Has(i) && !b.Teleporters.Has(i) &&
+		b.PowerUps[i] == 0 && b.bombAt(i) < 0 && b.playerAt(i) < 0
+}
+
+func (b *Board) shielded(n int) bool {
+	if b.Players[n].Shield == 0 {
+		return false
+	}
+	b.Players[n].Shield--
+	return true
+}
+
+// explode blows up the n-th bomb, like the engine's blasts: they reach
+// radius-1 cells away from the bomb, stop at walls, rocks and power-ups, and
+// go through rocks if piercing.
+func (b *Board) explode(n int) {
+	bomb := b.Bombs[n]
+	b.Bombs = append(b.Bombs[:n], b.Bombs[n+1:]...)
+	b.replenish = append(b.replenish, timer{bomb.Owner, b.Rules.TurnsToReplenish})
+
+	x, y := b.XY(bomb.Cell)
+	b.blast(bomb, x, y)
+	for _, dir := range [4][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}} {
+		for d := 1; d < bomb.Radius; d++ {
+			if !b.blast(bomb, x+dir[0]*d, y+dir[1]*d) {
+				break
+			}
+		}
+	}
+}
+
+// blast burns a cell, telling if the blast goes on past it.
+func (b *Board) blast(bomb Bomb, x, y int) bool {
+	if x < 0 || x >= b.Width || y < 0 || y >= b.Height {
+		return false
+	}
+	i := b.Index(x, y)
+	if b.Walls.Has(i) {
+		return false
+	}
+
+	for n := range b.Players {
+		p := &b.Players[n]
+		if !p.Alive || p.Cell != i || !b.hurts(bomb.Owner, n) || b.shielded(n) {
+			continue
+		}
+		p.Alive = false
+	}
+
+	b.Flames.Set(i)
+	b.FlameTimers[i] = uint8(clamp(b.Rules.TurnsToFlamout, 1, 255))
+	switch {
+	case b.Rocks.Has(i):
+		return bomb.Pierce
+	case b.PowerUps[i] != 0:
+		b.PowerUps[i] = 0
+		return false
+	}
+	return true
+}
+
+func (b *Board) hurts(bomber, victim int) bool {
+	if bomber < 0 || b.Rules.FriendlyFire || bomber == victim || b.Players[bomber].Team == "" {
+		return true
+	}
+	return b.Players[bomber].Team != b.Players[victim].Team
+}
+
+func (b *Board) applyPowerUp(n, pu int) {
+	s := &player.State{
+		MaxBomb: b.Players[n].MaxBomb, MaxRadius: b.Players[n].Radius,
+		Speed: b.Players[n].Speed, Shield: b.Players[n].Shield,
+		CursedTurns: b.Players[n].CursedTurns,
+		CanKick:     b.Players[n].CanKick, CanRemote: b.Players[n].CanRemote,
+		Pierce: b.Players[n].Pierce,
+	}
+	powerup.Catalogue[pu].Apply(s)
+	p := &b.Players[n]
+	p.MaxBomb, p.Radius = s.MaxBomb, s.MaxRadius
+	p.Speed, p.Shield, p.CursedTurns = s.Speed, s.Shield, s.CursedTurns
+	p.CanKick, p.CanRemote, p.Pierce = s.CanKick, s.CanRemote, s.Pierce
+}
+
+// Alive lists the indexes of the players still alive.
+func (b *Board) Alive() []int {
+	var alive []int
+	for n := range b.Players {
+		if b.Players[n].Alive {
+			alive = append(alive, n)
+		}
+	}
+	return alive
+}
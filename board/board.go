@@ -5,8 +5,8 @@ import (
 	"github.com/aybabtme/bomberman/game"
 	"github.com/aybabtme/bomberman/objects"
 	"github.com/aybabtme/bomberman/player"
+	"github.com/aybabtme/bomberman/powerup"
 	"github.com/nsf/termbox-go"
-	"math/rand"
 )
 
 type Board [][]*cell.Cell
@@ -19,72 +19,24 @@ func newBoard(x, y int) Board {
 	return b
 }
 
-func SetupBoard(g *game.Game, x, y, rockFreeRadius int, rockDensity float64) Board {
-	board := newBoard(x, y)
-
-	freeCells := board.setupMap()
-	rockPlaced := board.setupRocks(freeCells, rockDensity)
-	cleared := board.clearAroundPlayers(g.Players, rockFreeRadius)
-	rockPlaced -= cleared
-
-	bombRocksLeft := rockPlaced / 2
-	radiusRocksLeft := rockPlaced / 2
-
-	onlyRocks := func(c *cell.Cell) bool { return c.Top() == objects.Rock }
+// hidePowerUps places the power-ups of the game under the rocks of the board
+// that don't already hide something.
+func (b Board) hidePowerUps(g *game.Game) *powerup.Placement {
+	rocks := []powerup.Spot{}
+	onlyRocks := func(c *cell.Cell) bool { return c.Top() == objects.Rock && c.Depth() == 2 }
+	b.filter(onlyRocks, func(c *cell.Cell) {
+		rocks = append(rocks, powerup.Spot{X: c.X, Y: c.Y})
+	})
 
-	putPwrUpUnder := func(c *cell.Cell) {
+	placement := g.PowerUps.Place(rocks, len(b), len(b[0]))
+	for _, placed := range placement.Placed {
+		pu, _ := powerup.Lookup(placed.Kind)
+		c := b[placed.X][placed.Y]
 		rock, _ := c.Pop()
-		switch rand.Intn(2) {
-		case 0:
-			g.TryPutRadiusPU(c, radiusRocksLeft)
-			radiusRocksLeft--
-		case 1:
-			g.TryPutBombPU(c, bombRocksLeft)
-			bombRocksLeft--
-		}
+		c.Push(pu.Object)
 		c.Push(rock)
 	}
-	board.filter(onlyRocks, putPwrUpUnder)
-
-	return board
-}
-
-func (b Board) setupMap() (free int) {
-	b.forEachIndex(func(_ *cell.Cell, x, y int) {
-		b[x][y] = cell.NewCell(objects.Ground, x, y)
-		switch {
-		case
-			x == 0 || x == len(b)-1,    // Left and right
-			y == 0 || y == len(b[0])-1, // Top and bottom
-			y%2 == 0 && x%2 == 0:       // Every second cell
-			b[x][y].Push(objects.Wall)
-		default:
-			free++
-		}
-	})
-	return
-}
-
-func (b Board) setupRocks(freeCells int, densityPercent float64) int {
-	needRock := int(float64(freeCells) * densityPercent)
-	rockPlaced := 0
-	rockProb := func(rockLeft, freeCell int) float64 {
-		return float64(rockLeft) / float64(freeCell)
-	}
-
-	groundTest := func(c *cell.Cell) bool { return c.Top() == objects.Ground }
-
-	b.filter(groundTest, func(c *cell.Cell) {
-		prob := rockProb(needRock, freeCells)
-		roll := rand.Float64()
-		if roll < prob {
-			needRock--
-			rockPlaced++
-			c.Push(objects.Rock)
-		}
-		freeCells--
-	})
-	return rockPlaced
+	return placement
 }
 
 func (b Board) clearAroundPlayers(players map[*player.State]player.Player, radius int) (removed int) {
@@ -105,32 +57,61 @@ func (b Board) clearAroundPlayers(players map[*player.State]player.Player, radiu
 	return
 }
 
+// Grid is a board players can be shown and walk on. Board implements it, and
+// so do the compact boards of package bitboard used in simulations.
+type Grid interface {
+	Traversable(x, y int) bool
+	Clone(now int) [][]*cell.Exported
+	CloneFor(now int, v game.Vision, viewers []*player.State) [][]*cell.Exported
+}
+
+var _ Grid = Board(nil)
+
+// Traversable tells if a player can walk on the cell at (x, y).
 func (b Board) Traversable(x, y int) bool {
 	return b[x][y].Top().Traversable()
 }
 
-func (b Board) Draw(players map[*player.State]player.Player) {
-	b.forEach(func(c *cell.Cell) {
-		c.Top().Draw(c.X, c.Y)
+// Teleport tells where a player stepping on (x, y) is sent: if the cell holds
+// a teleporter, to the next one, unless something is on it.
+func (b Board) Teleport(x, y int) (int, int, bool) {
+	if !holds(b[x][y], objects.Teleporter) {
+		return x, y, false
+	}
+	tx, ty := NextTeleporter(len(b), len(b[0]), x, y, func(x, y int) bool {
+		return holds(b[x][y], objects.Teleporter)
 	})
+	if b[tx][ty].Top() != objects.Teleporter {
+		return x, y, false
+	}
+	return tx, ty, true
+}
 
-	for state := range players {
-		if !state.Alive {
-			b[state.X][state.Y].Remove(state.GameObject)
-			continue
+// holds tells if a cell holds an object.
+func holds(c *cell.Cell, o cell.GameObject) bool {
+	for z := 0; z < c.Depth(); z++ {
+		if c.Layer(z) == o {
+			return true
 		}
 	}
+	return false
+}
 
+func (b Board) Draw() {
+	b.forEach(func(c *cell.Cell) {
+		c.Top().Draw(c.X, c.Y)
+	})
 	termbox.Flush()
 }
 
-func (b Board) Clone() [][]*cell.Exported {
+// Clone exports the board as seen by players at turn now.
+func (b Board) Clone(now int) [][]*cell.Exported {
 	clone := make([][]*cell.Exported, len(b))
 	for i := range clone {
 		clone[i] = make([]*cell.Exported, len(b[0]))
 	}
 	b.forEach(func(c *cell.Cell) {
-		clone[c.X][c.Y] = c.Export()
+		clone[c.X][c.Y] = c.Export(now)
 	})
 	return clone
 }
@@ -0,0 +1,169 @@
+package board
+
+import (
+	"github.com/aybabtme/bomberman/cell"
+	"github.com/aybabtme/bomberman/game"
+	"github.com/aybabtme/bomberman/player"
+)
+
+// Feed exports the board to players every turn. It only re-exports the cells
+// that changed, and shares the export between all the players that see the
+// whole board. Players asking for deltas get what changed since their last
+// update, and a keyframe from time to time.
+type Feed struct {
+	board    Board
+	now      int
+	versions [][]int
+	// shared is the whole board as exported on the current turn. It's
+	// never modified once handed out, only replaced.
+	shared  [][]*cell.Exported
+	changes []change
+	// Per player following deltas: the view they were last sent and the turn
+	// of their last keyframe.
+	views     map[*player.State][][]*cell.Exported
+	keyframes map[*player.State]int
+}
+
+type change struct {
+	x, y     int
+	old, new *cell.Exported
+}
+
+// Feed creates a feed of the board, exported at turn now.
+func (b Board) Feed(now int) *Feed {
+	f := &Feed{
+		board:     b,
+		now:       now,
+		versions:  make([][]int, len(b)),
+		shared:    make([][]*cell.Exported, len(b)),
+		views:     make(map[*player.State][][]*cell.Exported),
+		keyframes: make(map[*player.State]int),
+	}
+	b.forEachIndex(func(c *cell.Cell, x, y int) {
+		if y == 0 {
+			f.versions[x] = make([]int, len(b[x]))
+			f.shared[x] = make([]*cell.Exported, len(b[x]))
+		}
+		f.versions[x][y] = c.Version()
+		f.shared[x][y] = c.Export(now)
+	})
+	return f
+}
+
+// Advance re-exports the cells that changed since the last turn. Call it once
+// per turn, before updating players.
+func (f *Feed) Advance(now int) {
+	f.now = now
+	f.changes = f.changes[:0]
+	next := make([][]*cell.Exported, len(f.shared))
+	for x := range f.shared {
+		next[x] = make([]*cell.Exported, len(f.shared[x]))
+		copy(next[x], f.shared[x])
+		for y, old := range f.shared[x] {
+			c := f.board[x][y]
+			if c.Version() == f.versions[x][y] && !old.Timed() {
+				continue
+			}
+			f.versions[x][y] = c.Version()
+			e := c.Export(now)
+			if !e.Equal(old) {
+				next[x][y] = e
+				f.changes = append(f.changes, change{x, y, old, e})
+			}
+		}
+	}
+	f.shared = next
+}
+
+// Update fills the board, or the delta, that a player gets this turn.
+func (f *Feed) Update(g *game.Game, pState *player.State) {
+	allies := g.Allies(pState)
+	limited := g.Vision.Limited() && len(allies) != 0
+
+	view := f.shared
+	if limited {
+		view = f.board.CloneFor(f.now, g.Vision, allies)
+	}
+
+	if pState.KeyframeEvery <= 0 {
+		pState.Board, pState.Delta = view, nil
+		return
+	}
+
+	last, ok := f.views[pState]
+	if !ok || f.now-f.keyframes[pState] >= pState.KeyframeEvery {
+		f.views[pState] = view
+		f.keyframes[pState] = f.now
+		pState.Board = view
+		pState.Delta = &player.Delta{Turn: f.now, Keyframe: true}
+		return
+	}
+
+	changes := f.changes
+	if limited {
+		changes = nil
+		for x := range view {
+			for y, e := range view[x] {
+				if !e.Equal(last[x][y]) {
+					changes = append(changes, change{x, y, last[x][y], e})
+				}
+			}
+		}
+	}
+	f.views[pState] = view
+	pState.Board = nil
+	pState.Delta = newDelta(f.now, changes)
+}
+
+// Dropped tells the feed that a player missed its update. Players following
+// deltas get a keyframe on their next update, since they can't catch up.
+func (f *Feed) Dropped(pState *player.State) {
+	delete(f.views, pState)
+}
+
+func newDelta(now int, changes []change) *player.Delta {
+	d := &player.Delta{Turn: now}
+	for _, ch := range changes {
+		d.Cells = append(d.Cells, player.CellChange{X: ch.x, Y: ch.y, Cell: ch.new})
+
+		// Objects present in one export and not the other, with timers left
+		// out since they change every turn.
+		count := make(map[cell.Layer]int)
+		for _, l := range ch.old.Layers {
+			l.TurnsLeft = 0
+			count[l]--
+		}
+		for _, l := range ch.new.Layers {
+			l.TurnsLeft = 0
+			count[l]++
+		}
+		for _, l := range ch.new.Layers {
+			if l.TurnsLeft = 0; count[l] > 0 {
+				count[l]--
+				d.Spawned = append(d.Spawned, player.Object{X: ch.x, Y: ch.y, Layer: l})
+			}
+		}
+		for _, l := range ch.old.Layers {
+			if l.TurnsLeft = 0; count[l] < 0 {
+				count[l]++
+				d.Removed = append(d.Removed, player.Object{X: ch.x, Y: ch.y, Layer: l})
+			}
+		}
+	}
+
+	for _, gone := range d.Removed {
+		if gone.Layer.Kind != cell.Player {
+			continue
+		}
+		for _, came := range d.Spawned {
+			if came.Layer.Kind == cell.Player && came.Layer.Name == gone.Layer.Name {
+				d.Moved = append(d.Moved, player.PlayerMove{
+					Name:  gone.Layer.Name,
+					FromX: gone.X, FromY: gone.Y,
+					X: came.X, Y: came.Y,
+				})
+			}
+		}
+	}
+	return d
+}
@@ -0,0 +1,74 @@
+package board_test
+
+import (
+	"github.com/aybabtme/bomberman/board"
+	"github.com/aybabtme/bomberman/game"
+	"github.com/aybabtme/bomberman/objects"
+	"github.com/aybabtme/bomberman/player"
+	"github.com/aybabtme/bomberman/powerup"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFeedSendsDeltasBetweenKeyframes(t *testing.T) {
+	m, err := board.LoadMap(strings.NewReader("#####\n#1.2#\n#####\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := game.NewGame(time.Second, powerup.Distribution{})
+	defer g.TurnTick.Stop()
+
+	p1 := &player.State{Name: "p1", X: 1, Y: 1, Alive: true, KeyframeEvery: 3,
+		GameObject: &objects.TboxPlayer{Name: "p1"}}
+	p2 := &player.State{Name: "p2", X: 3, Y: 1, Alive: true,
+		GameObject: &objects.TboxPlayer{Name: "p2"}}
+	g.Players = map[*player.State]player.Player{p1: nil, p2: nil}
+	b, _ := board.SetupMapBoard(g, m, 0, rand.New(rand.NewSource(1)))
+
+	feed := b.Feed(0)
+	feed.Update(g, p1)
+	if p1.Board == nil || p1.Delta == nil || !p1.Delta.Keyframe {
+		t.Fatalf("want a keyframe first, got %+v", p1.Delta)
+	}
+
+	b[1][1].Remove(p1.GameObject)
+	b[2][1].Push(p1.GameObject)
+	feed.Advance(1)
+	feed.Update(g, p1)
+	feed.Update(g, p2)
+
+	if p2.Board == nil || p2.Delta != nil {
+		t.Errorf("want full boards for players not asking for deltas")
+	}
+	if p1.Board != nil || p1.Delta == nil || p1.Delta.Keyframe {
+		t.Fatalf("want a delta, got %+v", p1.Delta)
+	}
+	if len(p1.Delta.Cells) != 2 {
+		t.Errorf("want 2 changed cells, got %+v", p1.Delta.Cells)
+	}
+	moved := p1.Delta.Moved
+	if len(moved) != 1 || moved[0] != (player.PlayerMove{Name: "p1", FromX: 1, FromY: 1, X: 2, Y: 1}) {
+		t.Errorf("want p1 moving from (1, 1) to (2, 1), got %+v", moved)
+	}
+
+	feed.Advance(2)
+	feed.Update(g, p1)
+	if len(p1.Delta.Cells) != 0 {
+		t.Errorf("want nothing changed, got %+v", p1.Delta.Cells)
+	}
+
+	feed.Advance(3)
+	feed.Update(g, p1)
+	if !p1.Delta.Keyframe {
+		t.Errorf("want a keyframe every 3 turns")
+	}
+
+	feed.Dropped(p1)
+	feed.Advance(4)
+	feed.Update(g, p1)
+	if !p1.Delta.Keyframe {
+		t.Errorf("want a keyframe after a dropped update")
+	}
+}
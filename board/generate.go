@@ -0,0 +1,414 @@
+package board
+
+import (
+	"fmt"
+	"github.com/aybabtme/bomberman/game"
+	"github.com/aybabtme/bomberman/powerup"
+	"math/rand"
+)
+
+// Generator creates arenas procedurally. Generated maps only use walls,
+// rocks and ground, and are validated like hand-designed maps: every spawn
+// can reach every other once rocks are destroyed.
+type Generator interface {
+	Generate(width, height int, rnd *rand.Rand) (*Map, error)
+}
+
+// Generators are the available generators, by name.
+var Generators = []string{"classic", "mirror", "rotational", "quadrants", "cave", "maze"}
+
+// NewGenerator creates a generator by name. Rocks cover rockDensity of the
+// free cells, a fraction between 0 and 1, except within rockFreeRadius of
+// spawns.
+func NewGenerator(name string, rockFreeRadius int, rockDensity float64) (Generator, error) {
+	if rockDensity < 0 || rockDensity > 1 {
+		return nil, fmt.Errorf("rock density must be between 0 and 1, got %v", rockDensity)
+	}
+	rocks := rockLayout{rockFreeRadius, rockDensity}
+	switch name {
+	case "classic":
+		return &Classic{rocks}, nil
+	case "mirror":
+		return &Symmetric{rocks, false}, nil
+	case "rotational":
+		return &Symmetric{rocks, true}, nil
+	case "quadrants":
+		return &Quadrants{rocks}, nil
+	case "cave":
+		return &Cave{rocks, 0.45, 4}, nil
+	case "maze":
+		return &Maze{rocks, 0.1}, nil
+	}
+	return nil, fmt.Errorf("unknown generator %q, want one of %v", name, Generators)
+}
+
+// SetupBoard creates the board of a game from a generated arena and hides the
+// power-ups of the game under its rocks. The placement of the power-ups is
+// returned for debugging.
+func SetupBoard(g *game.Game, gen Generator, width, height int, seed int64) (Board, *powerup.Placement, error) {
+	rng := rand.New(rand.NewSource(seed))
+	m, err := gen.Generate(width, height, rng)
+	if err != nil {
+		return nil, nil, err
+	}
+	board, placement := SetupMapBoard(g, m, 0, rng)
+	return board, placement, nil
+}
+
+// rockLayout says where generators may put rocks.
+type rockLayout struct {
+	RockFreeRadius int
+	RockDensity    float64
+}
+
+// Classic is the original arena: a pillar every second cell and rocks rolled
+// independently on each free cell.
+type Classic struct {
+	rockLayout
+}
+
+func (c *Classic) Generate(width, height int, rnd *rand.Rand) (*Map, error) {
+	m := newPillarMap(width, height)
+	free := m.freeCells()
+	needRock := int(float64(len(free)) * c.RockDensity)
+	for i, s := range free {
+		prob := float64(needRock) / float64(len(free)-i)
+		if rnd.Float64() < prob {
+			needRock--
+			m.tiles[s.X][s.Y] = '%'
+		}
+	}
+	m.clearAroundSpawns(c.RockFreeRadius)
+	return m, m.Validate()
+}
+
+// Symmetric rolls the rocks of the top-left quadrant and mirrors them over
+// both axes or, if Rotational, rotates them by half a turn around the center,
+// so no corner has an advantage.
+type Symmetric struct {
+	rockLayout
+	Rotational bool
+}
+
+func (s *Symmetric) Generate(width, height int, rnd *rand.Rand) (*Map, error) {
+	m := newPillarMap(width, height)
+	for _, spot := range m.freeCells() {
+		if !m.isRepresentative(spot, s.Rotational) || rnd.Float64() >= s.RockDensity {
+			continue
+		}
+		for _, image := range m.images(spot, s.Rotational) {
+			if m.tiles[image.X][image.Y] == '.' {
+				m.tiles[image.X][image.Y] = '%'
+			}
+		}
+	}
+	m.clearAroundSpawns(s.RockFreeRadius)
+	return m, m.Validate()
+}
+
+// isRepresentative tells if a spot stands for all its symmetric images, so
+// that each orbit is rolled only once.
+func (m *Map) isRepresentative(s powerup.Spot, rotational bool) bool {
+	for _, image := range m.images(s, rotational) {
+		if image.X < s.X || (image.X == s.X && image.Y < s.Y) {
+			return false
+		}
+	}
+	return true
+}
+
+// images lists the spots symmetric to s, including s.
+func (m *Map) images(s powerup.Spot, rotational bool) []powerup.Spot {
+	mx, my := m.Width-1-s.X, m.Height-1-s.Y
+	if rotational {
+		return []powerup.Spot{s, {X: mx, Y: my}}
+	}
+	return []powerup.Spot{s, {X: mx, Y: s.Y}, {X: s.X, Y: my}, {X: mx, Y: my}}
+}
+
+// Quadrants puts rocks on exactly the same fraction of the free cells of
+// every quadrant.
+type Quadrants struct {
+	rockLayout
+}
+
+func (q *Quadrants) Generate(width, height int, rnd *rand.Rand) (*Map, error) {
+	m := newPillarMap(width, height)
+	m.clearAroundSpawns(q.RockFreeRadius)
+
+	var quadrants [4][]powerup.Spot
+	for _, s := range m.freeCells() {
+		if m.nearSpawn(s, q.RockFreeRadius) {
+			continue
+		}
+		i := 0
+		if s.X >= width/2 {
+			i++
+		}
+		if s.Y >= height/2 {
+			i += 2
+		}
+		quadrants[i] = append(quadrants[i], s)
+	}
+
+	for _, free := range quadrants {
+		rnd.Shuffle(len(free), func(i, j int) { free[i], free[j] = free[j], free[i] })
+		for _, s := range free[:int(float64(len(free))*q.RockDensity+0.5)] {
+			m.tiles[s.X][s.Y] = '%'
+		}
+	}
+	return m, m.Validate()
+}
+
+// Cave carves cave-like arenas with a cellular automaton: walls are seeded at
+// random with WallDensity, then smoothed for Steps rounds. Pockets cut off
+// from the first spawn are joined to it by a tunnel, then rocks are rolled on
+// the open ground.
+type Cave struct {
+	rockLayout
+	WallDensity float64
+	Steps       int
+}
+
+func (c *Cave) Generate(width, height int, rnd *rand.Rand) (*Map, error) {
+	m := newMap(width, height)
+	m.Spawns = cornerSpawns(width, height)
+
+	for x := 1; x < width-1; x++ {
+		for y := 1; y < height-1; y++ {
+			if rnd.Float64() < c.WallDensity {
+				m.tiles[x][y] = '#'
+			}
+		}
+	}
+
+	for step := 0; step < c.Steps; step++ {
+		next := newMap(width, height)
+		for x := 1; x < width-1; x++ {
+			for y := 1; y < height-1; y++ {
+				// A cell becomes a wall when most of its neighbours are.
+				walls := 0
+				for i := x - 1; i <= x+1; i++ {
+					for j := y - 1; j <= y+1; j++ {
+						if (i != x || j != y) && m.tiles[i][j] == '#' {
+							walls++
+						}
+					}
+				}
+				if walls >= 5 || (walls == 4 && m.tiles[x][y] == '#') {
+					next.tiles[x][y] = '#'
+				}
+			}
+		}
+		m.tiles = next.tiles
+	}
+
+	for _, s := range m.Spawns {
+		m.carve(s, 1)
+	}
+	for _, s := range m.Spawns[1:] {
+		if !m.reachable(m.Spawns[0])[s.X][s.Y] {
+			m.tunnel(s, m.Spawns[0])
+		}
+	}
+	// Pockets that no one can reach are filled up.
+	seen := m.reachable(m.Spawns[0])
+	for x := 1; x < width-1; x++ {
+		for y := 1; y < height-1; y++ {
+			if !seen[x][y] {
+				m.tiles[x][y] = '#'
+			}
+		}
+	}
+
+	m.rollRocks(c.RockDensity, rnd)
+	m.clearAroundSpawns(c.RockFreeRadius)
+	return m, m.Validate()
+}
+
+// Maze lays walls as a maze, using a randomized depth-first search over every
+// second cell. Braid is the chance of knocking down extra walls to make
+// loops, since perfect mazes make for short games. Rocks are then rolled on
+// the corridors.
+type Maze struct {
+	rockLayout
+	Braid float64
+}
+
+func (mz *Maze) Generate(width, height int, rnd *rand.Rand) (*Map, error) {
+	if width%2 == 0 || height%2 == 0 {
+		return nil, fmt.Errorf("mazes need odd dimensions, got %dx%d", width, height)
+	}
+	m := newMap(width, height)
+	m.Spawns = cornerSpawns(width, height)
+	for x := 1; x < width-1; x++ {
+		for y := 1; y < height-1; y++ {
+			m.tiles[x][y] = '#'
+		}
+	}
+
+	start := powerup.Spot{X: 1, Y: 1}
+	m.tiles[start.X][start.Y] = '.'
+	stack := []powerup.Spot{start}
+	for len(stack) != 0 {
+		s := stack[len(stack)-1]
+		var next []powerup.Spot
+		for _, n := range []powerup.Spot{{X: s.X + 2, Y: s.Y}, {X: s.X - 2, Y: s.Y}, {X: s.X, Y: s.Y + 2}, {X: s.X, Y: s.Y - 2}} {
+			if n.X > 0 && n.X < width-1 && n.Y > 0 && n.Y < height-1 && m.tiles[n.X][n.Y] == '#' {
+				next = append(next, n)
+			}
+		}
+		if len(next) == 0 {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+		n := next[rnd.Intn(len(next))]
+		m.tiles[(s.X+n.X)/2][(s.Y+n.Y)/2] = '.'
+		m.tiles[n.X][n.Y] = '.'
+		stack = append(stack, n)
+	}
+
+	// Walls between two corridors, as opposed to pillars, can be knocked down.
+	for x := 1; x < width-1; x++ {
+		for y := 1; y < height-1; y++ {
+			if (x+y)%2 == 1 && m.tiles[x][y] == '#' && rnd.Float64() < mz.Braid {
+				m.tiles[x][y] = '.'
+			}
+		}
+	}
+
+	m.rollRocks(mz.RockDensity, rnd)
+	m.clearAroundSpawns(mz.RockFreeRadius)
+	return m, m.Validate()
+}
+
+///////////
+// Map building helpers
+
+// newMap creates a map of ground enclosed by walls, without spawns.
+func newMap(width, height int) *Map {
+	m := &Map{
+		Width:  width,
+		Height: height,
+		tiles:  make([][]rune, width),
+	}
+	for x := range m.tiles {
+		m.tiles[x] = make([]rune, height)
+		for y := range m.tiles[x] {
+			m.tiles[x][y] = '.'
+			if x == 0 || x == width-1 || y == 0 || y == height-1 {
+				m.tiles[x][y] = '#'
+			}
+		}
+	}
+	return m
+}
+
+// newPillarMap creates a map with a pillar every second cell and a spawn in
+// each corner.
+func newPillarMap(width, height int) *Map {
+	m := newMap(width, height)
+	m.Spawns = cornerSpawns(width, height)
+	for x := 2; x < width-1; x += 2 {
+		for y := 2; y < height-1; y += 2 {
+			m.tiles[x][y] = '#'
+		}
+	}
+	return m
+}
+
+// cornerSpawns are the corners players start in, in the same order as the
+// default arena.
+func cornerSpawns(width, height int) []powerup.Spot {
+	return []powerup.Spot{
+		{X: 1, Y: 1},
+		{X: width - 2, Y: height - 2},
+		{X: 1, Y: height - 2},
+		{X: width - 2, Y: 1},
+	}
+}
+
+// freeCells lists the ground cells of the map, column by column.
+func (m *Map) freeCells() []powerup.Spot {
+	free := []powerup.Spot{}
+	for x := range m.tiles {
+		for y, tile := range m.tiles[x] {
+			if tile == '.' {
+				free = append(free, powerup.Spot{X: x, Y: y})
+			}
+		}
+	}
+	return free
+}
+
+func (m *Map) rollRocks(density float64, rnd *rand.Rand) {
+	for _, s := range m.freeCells() {
+		if rnd.Float64() < density {
+			m.tiles[s.X][s.Y] = '%'
+		}
+	}
+}
+
+func (m *Map) nearSpawn(s powerup.Spot, radius int) bool {
+	for _, spawn := range m.Spawns {
+		if abs(s.X-spawn.X) <= radius && abs(s.Y-spawn.Y) <= radius {
+			return true
+		}
+	}
+	return false
+}
+
+// clearAroundSpawns removes the rocks within radius of spawns.
+func (m *Map) clearAroundSpawns(radius int) {
+	for _, spawn := range m.Spawns {
+		for x := max(spawn.X-radius, 1); x <= min(spawn.X+radius, m.Width-2); x++ {
+			for y := max(spawn.Y-radius, 1); y <= min(spawn.Y+radius, m.Height-2); y++ {
+				if m.tiles[x][y] == '%' {
+					m.tiles[x][y] = '.'
+				}
+			}
+		}
+	}
+}
+
+// carve turns everything within radius of s into ground.
+func (m *Map) carve(s powerup.Spot, radius int) {
+	for x := max(s.X-radius, 1); x <= min(s.X+radius, m.Width-2); x++ {
+		for y := max(s.Y-radius, 1); y <= min(s.Y+radius, m.Height-2); y++ {
+			m.tiles[x][y] = '.'
+		}
+	}
+}
+
+// tunnel digs a straight corridor from one spot to the other, horizontally
+// then vertically.
+func (m *Map) tunnel(from, to powerup.Spot) {
+	x, y := from.X, from.Y
+	for x != to.X {
+		m.tiles[x][y] = '.'
+		x += sign(to.X - x)
+	}
+	for y != to.Y {
+		m.tiles[x][y] = '.'
+		y += sign(to.Y - y)
+	}
+}
+
+// Integer math
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
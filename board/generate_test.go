@@ -0,0 +1,112 @@
+package board
+
+import (
+	"github.com/aybabtme/bomberman/powerup"
+	"math/rand"
+	"testing"
+)
+
+func TestGeneratorsMakeValidArenas(t *testing.T) {
+	for _, name := range Generators {
+		gen, err := NewGenerator(name, 1, 0.5)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for seed := int64(0); seed < 20; seed++ {
+			m, err := gen.Generate(51, 23, rand.New(rand.NewSource(seed)))
+			if err != nil {
+				t.Errorf("%s, seed %d: %v", name, seed, err)
+				continue
+			}
+			for _, s := range m.Spawns {
+				if m.tiles[s.X][s.Y] != '.' {
+					t.Errorf("%s, seed %d: spawn %v is on %q", name, seed, s, m.tiles[s.X][s.Y])
+				}
+			}
+		}
+	}
+}
+
+func TestGeneratorsRefuseBadDensities(t *testing.T) {
+	for _, density := range []float64{-0.1, 1.5} {
+		if _, err := NewGenerator("quadrants", 1, density); err == nil {
+			t.Errorf("want rock density %v refused", density)
+		}
+	}
+}
+
+func TestGeneratorsAreSeeded(t *testing.T) {
+	for _, name := range Generators {
+		gen, _ := NewGenerator(name, 1, 0.5)
+		first, _ := gen.Generate(51, 23, rand.New(rand.NewSource(42)))
+		second, _ := gen.Generate(51, 23, rand.New(rand.NewSource(42)))
+		if !sameTiles(first, second) {
+			t.Errorf("%s: same seed gave different arenas", name)
+		}
+	}
+}
+
+func TestSymmetricGenerators(t *testing.T) {
+	for _, rotational := range []bool{false, true} {
+		gen := &Symmetric{rockLayout{1, 0.5}, rotational}
+		m, err := gen.Generate(51, 23, rand.New(rand.NewSource(7)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for x := range m.tiles {
+			for y, tile := range m.tiles[x] {
+				for _, image := range m.images(spot(x, y), rotational) {
+					if other := m.tiles[image.X][image.Y]; other != tile {
+						t.Fatalf("rotational=%v: (%d, %d) is %q but its image %v is %q",
+							rotational, x, y, tile, image, other)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestQuadrantsHaveSameDensity(t *testing.T) {
+	gen := &Quadrants{rockLayout{1, 0.5}}
+	m, err := gen.Generate(51, 23, rand.New(rand.NewSource(3)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rocks, free [4]int
+	for x := 1; x < m.Width-1; x++ {
+		for y := 1; y < m.Height-1; y++ {
+			if m.tiles[x][y] == '#' || m.nearSpawn(spot(x, y), 1) {
+				continue
+			}
+			q := 0
+			if x >= m.Width/2 {
+				q++
+			}
+			if y >= m.Height/2 {
+				q += 2
+			}
+			free[q]++
+			if m.tiles[x][y] == '%' {
+				rocks[q]++
+			}
+		}
+	}
+	for q := range rocks {
+		if want := int(float64(free[q])*0.5 + 0.5); rocks[q] != want {
+			t.Errorf("quadrant %d: want %d rocks out of %d, got %d", q, want, free[q], rocks[q])
+		}
+	}
+}
+
+func sameTiles(a, b *Map) bool {
+	for x := range a.tiles {
+		if string(a.tiles[x]) != string(b.tiles[x]) {
+			return false
+		}
+	}
+	return true
+}
+
+func spot(x, y int) powerup.Spot {
+	return powerup.Spot{X: x, Y: y}
+}
@@ -0,0 +1,254 @@
+package board
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/aybabtme/bomberman/cell"
+	"github.com/aybabtme/bomberman/game"
+	"github.com/aybabtme/bomberman/objects"
+	"github.com/aybabtme/bomberman/powerup"
+	"io"
+	"math/rand"
+	"strings"
+	"unicode"
+)
+
+// Map is an arena designed by hand. Maps are plain text, one line per row of
+// the board, one character per cell:
+//
+//	#   wall
+//	%   rock
+//	?   rock or ground, at random, following the rock density
+//	.   ground (a space works too)
+//	1-9 spawn point of the n-th player, on the ground
+//	b r k d p s o x  a power-up lying on the ground: bomb, radius, kick,
+//	                 remote detonator, pierce, speed, shield (o) or skull (x)
+//	B R K D P S O X  the same power-up, hidden under a rock
+//	@   teleporter, sending players who step on it to the next teleporter
+//	    in reading order, the last one to the first, unless it's taken
+//
+// Lines starting with ';' are comments and empty lines are ignored. The
+// border of the map must be made of walls, and every spawn point must be
+// reachable from the others once rocks are destroyed. Teleporters come at
+// least in pairs.
+type Map struct {
+	Width, Height int
+	// Spawns are the spawn points, in player order.
+	Spawns []powerup.Spot
+	// tiles are indexed by [x][y], like boards.
+	tiles [][]rune
+}
+
+// mapPowerUps are the symbols of power-ups, in their lying-on-the-ground
+// form.
+var mapPowerUps = map[rune]powerup.Kind{
+	'b': powerup.Bomb,
+	'r': powerup.Radius,
+	'k': powerup.Kick,
+	'd': powerup.Remote,
+	'p': powerup.Pierce,
+	's': powerup.Speed,
+	'o': powerup.Shield,
+	'x': powerup.Skull,
+}
+
+// LoadMap reads and validates a map.
+func LoadMap(r io.Reader) (*Map, error) {
+	var rows [][]rune
+	var lines []int
+
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" || strings.HasPrefix(line, ";") {
+			continue
+		}
+		rows = append(rows, []rune(line))
+		lines = append(lines, n)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading map, %v", err)
+	}
+
+	if len(rows) < 3 {
+		return nil, fmt.Errorf("map must have at least 3 rows, got %d", len(rows))
+	}
+	width := len(rows[0])
+	if width < 3 {
+		return nil, fmt.Errorf("line %d: map must have at least 3 columns, got %d", lines[0], width)
+	}
+
+	m := &Map{
+		Width:  width,
+		Height: len(rows),
+		tiles:  make([][]rune, width),
+	}
+	for x := range m.tiles {
+		m.tiles[x] = make([]rune, m.Height)
+	}
+
+	spawns := make(map[int]powerup.Spot)
+	for y, row := range rows {
+		if len(row) != width {
+			return nil, fmt.Errorf("line %d: row has %d columns, expected %d like the first row",
+				lines[y], len(row), width)
+		}
+		for x, tile := range row {
+			if tile == ' ' {
+				tile = '.'
+			}
+			switch {
+			case tile >= '1' && tile <= '9':
+				n := int(tile - '0')
+				if _, ok := spawns[n]; ok {
+					return nil, fmt.Errorf("line %d, column %d: spawn %d appears twice",
+						lines[y], x+1, n)
+				}
+				spawns[n] = powerup.Spot{X: x, Y: y}
+			case tile == '#', tile == '%', tile == '?', tile == '.', tile == '@':
+			default:
+				if _, ok := mapPowerUps[unicode.ToLower(tile)]; !ok {
+					return nil, fmt.Errorf("line %d, column %d: unknown tile %q",
+						lines[y], x+1, tile)
+				}
+			}
+			m.tiles[x][y] = tile
+		}
+	}
+
+	for n := 1; n <= len(spawns); n++ {
+		spot, ok := spawns[n]
+		if !ok {
+			return nil, fmt.Errorf("spawns must be numbered 1 to %d, spawn %d is missing", len(spawns), n)
+		}
+		m.Spawns = append(m.Spawns, spot)
+	}
+
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Validate verifies that the map is enclosed by walls, and that every spawn
+// can walk to the first one once all the rocks are destroyed.
+func (m *Map) Validate() error {
+	for x := 0; x < m.Width; x++ {
+		for y := 0; y < m.Height; y++ {
+			onBorder := x == 0 || x == m.Width-1 || y == 0 || y == m.Height-1
+			if onBorder && m.tiles[x][y] != '#' {
+				return fmt.Errorf("border must be a wall, got %q at (%d, %d)", m.tiles[x][y], x, y)
+			}
+		}
+	}
+
+	if len(m.Spawns) < 2 {
+		return fmt.Errorf("map must have at least 2 spawns, got %d", len(m.Spawns))
+	}
+	for i, s := range m.Spawns {
+		if m.tiles[s.X][s.Y] == '#' {
+			return fmt.Errorf("spawn %d at (%d, %d) is in a wall", i+1, s.X, s.Y)
+		}
+	}
+
+	teleporters := 0
+	for x := range m.tiles {
+		for _, tile := range m.tiles[x] {
+			if tile == '@' {
+				teleporters++
+			}
+		}
+	}
+	if teleporters == 1 {
+		return fmt.Errorf("map has a single teleporter, leading nowhere")
+	}
+
+	start := m.Spawns[0]
+	seen := m.reachable(start)
+	for i, s := range m.Spawns[1:] {
+		if !seen[s.X][s.Y] {
+			return fmt.Errorf("spawn %d at (%d, %d) can't reach spawn 1 at (%d, %d)",
+				i+2, s.X, s.Y, start.X, start.Y)
+		}
+	}
+	return nil
+}
+
+// reachable finds the cells that can be walked or teleported to from start,
+// once all the rocks are destroyed.
+func (m *Map) reachable(start powerup.Spot) [][]bool {
+	seen := make([][]bool, m.Width)
+	for x := range seen {
+		seen[x] = make([]bool, m.Height)
+	}
+
+	seen[start.X][start.Y] = true
+	queue := []powerup.Spot{start}
+	for len(queue) != 0 {
+		s := queue[0]
+		queue = queue[1:]
+		nexts := []powerup.Spot{{X: s.X + 1, Y: s.Y}, {X: s.X - 1, Y: s.Y}, {X: s.X, Y: s.Y + 1}, {X: s.X, Y: s.Y - 1}}
+		if m.tiles[s.X][s.Y] == '@' {
+			x, y := NextTeleporter(m.Width, m.Height, s.X, s.Y, func(x, y int) bool { return m.tiles[x][y] == '@' })
+			nexts = append(nexts, powerup.Spot{X: x, Y: y})
+		}
+		for _, next := range nexts {
+			if seen[next.X][next.Y] || m.tiles[next.X][next.Y] == '#' {
+				continue
+			}
+			seen[next.X][next.Y] = true
+			queue = append(queue, next)
+		}
+	}
+	return seen
+}
+
+// SetupMapBoard creates the board of a game from a map. Random rocks are
+// rolled with the given density from rng, so boards are the same for the same
+// seed, then the power-ups of the game are hidden under the rocks, in addition
+// to those fixed by the map.
+func SetupMapBoard(g *game.Game, m *Map, rockDensity float64, rng *rand.Rand) (Board, *powerup.Placement) {
+	board := newBoard(m.Width, m.Height)
+	board.forEachIndex(func(_ *cell.Cell, x, y int) {
+		c := cell.NewCell(objects.Ground, x, y)
+		board[x][y] = c
+
+		tile := m.tiles[x][y]
+		switch {
+		case tile == '#':
+			c.Push(objects.Wall)
+		case tile == '%':
+			c.Push(objects.Rock)
+		case tile == '@':
+			c.Push(objects.Teleporter)
+		case tile == '?':
+			if rng.Float64() < rockDensity {
+				c.Push(objects.Rock)
+			}
+		case unicode.IsLower(tile):
+			pu, _ := powerup.Lookup(mapPowerUps[tile])
+			c.Push(pu.Object)
+		case unicode.IsUpper(tile):
+			pu, _ := powerup.Lookup(mapPowerUps[unicode.ToLower(tile)])
+			c.Push(pu.Object)
+			c.Push(objects.Rock)
+		}
+	})
+
+	placement := board.hidePowerUps(g)
+	board.clearAroundPlayers(g.Players, 0)
+	return board, placement
+}
+
+// NextTeleporter finds where the teleporter at (x, y) leads on a board of the
+// given size: the next teleporter in reading order, the last one leading to
+// the first.
+func NextTeleporter(width, height, x, y int, isTeleporter func(x, y int) bool) (int, int) {
+	for i := 1; i < width*height; i++ {
+		n := (y*width + x + i) % (width * height)
+		if tx, ty := n%width, n/width; isTeleporter(tx, ty) {
+			return tx, ty
+		}
+	}
+	return x, y
+}
@@ -0,0 +1,91 @@
+package board_test
+
+import (
+	"github.com/aybabtme/bomberman/board"
+	"github.com/aybabtme/bomberman/game"
+	"github.com/aybabtme/bomberman/powerup"
+	"math/rand"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadMapSample(t *testing.T) {
+	fd, err := os.Open("../maps/crossroads.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fd.Close()
+
+	m, err := board.LoadMap(fd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Width != 19 || m.Height != 15 {
+		t.Errorf("want 19x15, got %dx%d", m.Width, m.Height)
+	}
+	if len(m.Spawns) != 4 || m.Spawns[1].X != 17 || m.Spawns[1].Y != 13 {
+		t.Errorf("unexpected spawns %v", m.Spawns)
+	}
+}
+
+func TestLoadMapRejectsInvalid(t *testing.T) {
+	tests := []struct {
+		name, arena, err string
+	}{
+		{"open border", "#####\n#1.2.\n#####\n", "border must be a wall"},
+		{"ragged", "#####\n#1.2#\n####\n", "expected 5 like the first row"},
+		{"unknown tile", "#####\n#1*2#\n#####\n", "unknown tile '*'"},
+		{"lone teleporter", "#####\n#1@2#\n#####\n", "single teleporter"},
+		{"one spawn", "#####\n#1..#\n#####\n", "at least 2 spawns"},
+		{"missing spawn", "#####\n#1.3#\n#####\n", "spawn 2 is missing"},
+		{"walled in", "#####\n#1#2#\n#####\n", "spawn 2 at (3, 1) can't reach spawn 1"},
+	}
+	for _, tt := range tests {
+		_, err := board.LoadMap(strings.NewReader(tt.arena))
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: want error containing %q, got %v", tt.name, tt.err, err)
+		}
+	}
+}
+
+func TestTeleportersJoinSpawns(t *testing.T) {
+	m, err := board.LoadMap(strings.NewReader("#######\n#1@#@2#\n#######\n"))
+	if err != nil {
+		t.Fatalf("want spawns joined by teleporters, got %v", err)
+	}
+	g := game.NewGame(time.Second, powerup.Distribution{})
+	g.TurnTick.Stop()
+	b, _ := board.SetupMapBoard(g, m, 0, rand.New(rand.NewSource(1)))
+	if x, y, ok := b.Teleport(4, 1); !ok || x != 2 || y != 1 {
+		t.Errorf("want the last teleporter to lead to the first, got (%d, %d), %v", x, y, ok)
+	}
+}
+
+func TestSetupMapBoardFollowsSeed(t *testing.T) {
+	m, err := board.LoadMap(strings.NewReader("#########\n#1?????2#\n#???????#\n#3?????4#\n#########\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	setup := func(seed int64) string {
+		g := game.NewGame(time.Second, powerup.Distribution{})
+		g.TurnTick.Stop()
+		b, _ := board.SetupMapBoard(g, m, 0.5, rand.New(rand.NewSource(seed)))
+		rocks := ""
+		for y := 0; y < m.Height; y++ {
+			for x := 0; x < m.Width; x++ {
+				if b.Traversable(x, y) {
+					rocks += "."
+				} else {
+					rocks += "#"
+				}
+			}
+			rocks += "\n"
+		}
+		return rocks
+	}
+	if first, again := setup(1), setup(1); first != again {
+		t.Errorf("want the same rocks for the same seed, got\n%s\nthen\n%s", first, again)
+	}
+}
@@ -0,0 +1,102 @@
+package board
+
+import (
+	"github.com/aybabtme/bomberman/cell"
+	"github.com/aybabtme/bomberman/game"
+	"github.com/aybabtme/bomberman/objects"
+	"github.com/aybabtme/bomberman/player"
+)
+
+// CloneFor exports the board as seen by viewers at turn now. Cells none of
+// them can see are unknown. Without viewers, the whole board is seen.
+func (b Board) CloneFor(now int, v game.Vision, viewers []*player.State) [][]*cell.Exported {
+	if !v.Limited() || len(viewers) == 0 {
+		return b.Clone(now)
+	}
+	seen := b.Visible(v, viewers)
+	clone := make([][]*cell.Exported, len(b))
+	for x := range clone {
+		clone[x] = make([]*cell.Exported, len(b[0]))
+		for y := range clone[x] {
+			if seen[x][y] {
+				clone[x][y] = b[x][y].Export(now)
+			} else {
+				clone[x][y] = cell.ExportUnknown()
+			}
+		}
+	}
+	return clone
+}
+
+// Visible finds the cells any of the viewers can see.
+func (b Board) Visible(v game.Vision, viewers []*player.State) [][]bool {
+	return Sight(len(b), len(b[0]), func(x, y int) bool { return opaque(b[x][y]) }, v, viewers)
+}
+
+// Sight finds the cells any of the viewers can see on a board of the given
+// size, opaque telling which cells block the view.
+func Sight(width, height int, opaque func(x, y int) bool, v game.Vision, viewers []*player.State) [][]bool {
+	seen := make([][]bool, width)
+	for x := range seen {
+		seen[x] = make([]bool, height)
+	}
+	rad := v.Radius
+	if rad <= 0 {
+		rad = max(width, height)
+	}
+	for _, viewer := range viewers {
+		for x := max(viewer.X-rad, 0); x <= min(viewer.X+rad, width-1); x++ {
+			for y := max(viewer.Y-rad, 0); y <= min(viewer.Y+rad, height-1); y++ {
+				if seen[x][y] {
+					continue
+				}
+				dx, dy := x-viewer.X, y-viewer.Y
+				if v.Radius > 0 && dx*dx+dy*dy > v.Radius*v.Radius {
+					continue
+				}
+				if v.LineOfSight && !inSight(opaque, viewer.X, viewer.Y, x, y) {
+					continue
+				}
+				seen[x][y] = true
+			}
+		}
+	}
+	return seen
+}
+
+// inSight tells if nothing opaque stands on the line between two cells. The
+// cells at both ends can be opaque, you can see a wall.
+func inSight(opaque func(x, y int) bool, fromX, fromY, toX, toY int) bool {
+	// Bresenham's line
+	dx, dy := abs(toX-fromX), -abs(toY-fromY)
+	sx, sy := sign(toX-fromX), sign(toY-fromY)
+	err := dx + dy
+	x, y := fromX, fromY
+	for {
+		if x == toX && y == toY {
+			return true
+		}
+		if (x != fromX || y != fromY) && opaque(x, y) {
+			return false
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x += sx
+		}
+		if e2 <= dx {
+			err += dx
+			y += sy
+		}
+	}
+}
+
+// opaque tells if walls or rocks block the view through a cell.
+func opaque(c *cell.Cell) bool {
+	for z := 0; z < c.Depth(); z++ {
+		if o := c.Layer(z); o == objects.Wall || o == objects.Rock {
+			return true
+		}
+	}
+	return false
+}
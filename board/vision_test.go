@@ -0,0 +1,48 @@
+package board_test
+
+import (
+	"github.com/aybabtme/bomberman/board"
+	"github.com/aybabtme/bomberman/cell"
+	"github.com/aybabtme/bomberman/game"
+	"github.com/aybabtme/bomberman/player"
+	"github.com/aybabtme/bomberman/powerup"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCloneForHidesOutOfSight(t *testing.T) {
+	arena := "" +
+		"#########\n" +
+		"#1..%..2#\n" +
+		"#.#.#.#.#\n" +
+		"#.......#\n" +
+		"#########\n"
+	m, err := board.LoadMap(strings.NewReader(arena))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := game.NewGame(time.Second, powerup.Distribution{})
+	defer g.TurnTick.Stop()
+	b, _ := board.SetupMapBoard(g, m, 0, rand.New(rand.NewSource(1)))
+
+	viewer := &player.State{X: 1, Y: 1, Alive: true}
+
+	view := b.CloneFor(0, game.Vision{LineOfSight: true}, []*player.State{viewer})
+	if view[4][1].Top().Kind != cell.Rock {
+		t.Errorf("want to see the rock, got %+v", view[4][1].Top())
+	}
+	if view[6][1].Top().Kind != cell.Unknown {
+		t.Errorf("want cell behind the rock unknown, got %+v", view[6][1].Top())
+	}
+	if view[1][3].Top().Kind != cell.Ground {
+		t.Errorf("want to see down the first column, got %+v", view[1][3].Top())
+	}
+
+	view = b.CloneFor(0, game.Vision{Radius: 2}, []*player.State{viewer})
+	if view[3][1].Top().Kind != cell.Ground || view[4][1].Top().Kind != cell.Unknown {
+		t.Errorf("want to see 2 cells away and no further, got %+v and %+v",
+			view[3][1].Top(), view[4][1].Top())
+	}
+}
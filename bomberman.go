@@ -1,18 +1,27 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"github.com/aybabtme/bomberman/board"
+	"github.com/aybabtme/bomberman/config"
+	"github.com/aybabtme/bomberman/engine"
 	"github.com/aybabtme/bomberman/game"
+	"github.com/aybabtme/bomberman/keys"
 	"github.com/aybabtme/bomberman/logger"
 	"github.com/aybabtme/bomberman/objects"
 	"github.com/aybabtme/bomberman/player"
+	"github.com/aybabtme/bomberman/player/ai"
 	"github.com/aybabtme/bomberman/player/input"
-	"github.com/aybabtme/bomberman/scheduler"
+	"github.com/aybabtme/bomberman/player/remote"
+	"github.com/aybabtme/bomberman/powerup"
+	"github.com/aybabtme/bomberman/stats"
 	"github.com/aybabtme/bombertcp"
 	"github.com/nsf/termbox-go"
 	"math/rand"
+	"os"
 	"runtime"
+	"strings"
 	"time"
 )
 
@@ -28,129 +37,206 @@ const (
 )
 
 const (
-	LogLevel = logger.Info
-
 	RockFreeArea = 1
 	RockDensity  = 0.50
-
-	TotalRadiusPU = 20
-	TotalBombPU   = 20
-
-	DefaultMaxBomb    = 3
-	DefaultBombRadius = 3
-
-	TurnDuration = time.Millisecond * 200
-
-	TurnsToFlamout   = 3
-	TurnsToReplenish = 12
-	TurnsToExplode   = 10
 )
 
 var (
 	h, w int
 
-	log = logger.New("", "bomb.log", LogLevel)
-
-	leftTopCorner = player.State{
-		Name:         "p1",
-		X:            MinX,
-		Y:            MinY,
-		LastX:        -1,
-		LastY:        -1,
-		TurnDuration: TurnDuration,
-		Bombs:        0,
-		MaxBomb:      DefaultMaxBomb,
-		MaxRadius:    DefaultBombRadius,
-		Alive:        true,
-		GameObject:   &objects.TboxPlayer{"p1"},
-	}
-
-	rightBottomCorner = player.State{
-		Name:         "p2",
-		X:            MaxX,
-		Y:            MaxY,
-		LastX:        -1,
-		LastY:        -1,
-		TurnDuration: TurnDuration,
-		Bombs:        0,
-		MaxBomb:      DefaultMaxBomb,
-		MaxRadius:    DefaultBombRadius,
-		Alive:        true,
-		GameObject:   &objects.TboxPlayer{"p2"},
-	}
-
-	leftBottomCorner = player.State{
-		Name:         "p3",
-		X:            MinX,
-		Y:            MaxY,
-		LastX:        -1,
-		LastY:        -1,
-		TurnDuration: TurnDuration,
-		Bombs:        0,
-		MaxBomb:      DefaultMaxBomb,
-		MaxRadius:    DefaultBombRadius,
-		Alive:        true,
-		GameObject:   &objects.TboxPlayer{"p3"},
-	}
-
-	rightTopCorner = player.State{
-		Name:         "p4",
-		X:            MaxX,
-		Y:            MinY,
-		LastX:        -1,
-		LastY:        -1,
-		TurnDuration: TurnDuration,
-		Bombs:        0,
-		MaxBomb:      DefaultMaxBomb,
-		MaxRadius:    DefaultBombRadius,
-		Alive:        true,
-		GameObject:   &objects.TboxPlayer{"p4"},
+	rosterFile = flag.String("roster", "", "JSON file describing the players and teams of the match")
+	mapFile    = flag.String("map", "", "text file describing the arena, instead of a generated one")
+	generator  = flag.String("arena", "classic", "how to generate the arena: "+strings.Join(board.Generators, ", "))
+	ratings    = flag.String("ratings", "", "file holding the rating history, to rate the players after the match")
+	ratingSys  = flag.String("rating-system", "elo", "how players are rated: elo or trueskill")
+	statsFile  = flag.String("stats", "", "file to export the stats of the match to, as CSV if it ends in .csv, JSON otherwise")
+	spectate   = flag.Bool("spectate", false, "only watch the match, without a local player")
+	follow     = flag.String("follow", "", "player whose sight is shown when spectating, instead of the whole board")
+	keysFile   = flag.String("keys", "", "JSON file binding keys to the moves of local players and the controls of the game")
+
+	// log goes to stderr until flags say where it should go.
+	log     = logger.NewWriter("", os.Stderr, logger.Info)
+	logOpts = logFlags(flag.CommandLine, "info")
+
+	// spawns are the corners players start in on the default arena, in
+	// roster order.
+	spawns = []powerup.Spot{
+		{X: MinX, Y: MinY},
+		{X: MaxX, Y: MaxY},
+		{X: MinX, Y: MaxY},
+		{X: MaxX, Y: MinY},
+	}
+
+	// powerUps is how many of each power-up are hidden under rocks, unless the
+	// roster says otherwise.
+	powerUps = map[powerup.Kind]int{
+		powerup.Bomb:   20,
+		powerup.Radius: 20,
+		powerup.Kick:   4,
+		powerup.Remote: 2,
+		powerup.Pierce: 2,
+		powerup.Speed:  4,
+		powerup.Shield: 4,
+		powerup.Skull:  3,
+	}
+
+	defaultRoster = config.Roster{
+		Players: []config.Entry{
+			{Name: "p1", Kind: "local"},
+			{Name: "p2", Kind: "tcp", Addr: "0.0.0.0:40000"},
+		},
 	}
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "gym":
+			runGym(os.Args[2:])
+			return
+		case "bench":
+			runBench(os.Args[2:])
+			return
+		case "tournament":
+			runTournament(os.Args[2:])
+			return
+		case "ratings":
+			runRatings(os.Args[2:])
+			return
+		case "serve":
+			runServe(os.Args[2:])
+			return
+		case "spectate":
+			runSpectate(os.Args[2:])
+			return
+		case "client":
+			runClient(os.Args[2:])
+			return
+		}
+	}
+	flag.Parse()
+	logOpts.open()
 
 	log.Infof("Starting Bomberman")
 
-	log.Infof("TurnsToFlamout=%d", TurnsToFlamout)
-	log.Infof("TurnsToReplenish=%d", TurnsToReplenish)
-	log.Infof("TurnsToExplode=%d", TurnsToExplode)
+	rules := engine.DefaultRules
+	log.Infof("TurnsToFlamout=%d", rules.TurnsToFlamout)
+	log.Infof("TurnsToReplenish=%d", rules.TurnsToReplenish)
+	log.Infof("TurnsToExplode=%d", rules.TurnsToExplode)
 
-	game := game.NewGame(TurnDuration, TotalBombPU, TotalRadiusPU)
+	roster, err := loadRoster(*rosterFile)
+	if err != nil {
+		log.Fatalf("Loading roster: %v", err)
+	}
+	if *spectate {
+		if err := checkSpectated(roster, *follow); err != nil {
+			log.Fatalf("Spectating: %v", err)
+		}
+	}
 
-	log.Debugf("Initializing local player.")
-	localState := &leftTopCorner
-	localPlayer, inputChan := initLocalPlayer(*localState)
+	keysCfg, err := loadKeys(*keysFile)
+	if err != nil {
+		log.Fatalf("Loading keys: %v", err)
+	}
+
+	store, err := openRatings(*ratings, *ratingSys)
+	if err != nil {
+		log.Fatalf("Opening ratings: %v", err)
+	}
 
-	game.Players = map[*player.State]player.Player{
-		localState:         localPlayer,
-		&rightBottomCorner: bombertcp.NewTcpPlayer(rightBottomCorner, "0.0.0.0:40000", log),
+	dist, err := powerUpDistribution(roster)
+	if err != nil {
+		log.Fatalf("Reading power-ups of roster: %v", err)
+	}
+
+	game := game.NewGame(rules.TurnDuration, dist)
+	game.FriendlyFire = roster.FriendlyFire
+	game.Vision.Radius = roster.VisionRadius
+	game.Vision.LineOfSight = roster.LineOfSight
+
+	arena, err := loadMap(*mapFile)
+	if err != nil {
+		log.Fatalf("Loading map: %v", err)
+	}
+	playerSpawns := spawns
+	if arena != nil {
+		playerSpawns = arena.Spawns
+	}
+
+	log.Debugf("Initializing players.")
+	controls, err := setupPlayers(game, rules, roster, playerSpawns, keysCfg)
+	if err != nil {
+		log.Fatalf("Setting up players: %v", err)
 	}
 
 	runtime.GOMAXPROCS(1 + len(game.Players))
 
 	log.Debugf("Setup board.")
-	board := board.SetupBoard(game, MaxX+2, MaxY+2, RockFreeArea, RockDensity)
+	board, placement, err := setupBoard(game, arena, roster.Seed)
+	if err != nil {
+		log.Fatalf("Generating arena: %v", err)
+	}
+	if len(placement.Unplaced) != 0 {
+		log.Warnf("Not enough rocks to hide all power-ups.")
+	}
+	log.Debugf("Power-ups: %v", placement)
+	matchLog, closeLog := logOpts.matchLog(time.Now().Format("20060102-150405"))
+	defer closeLog()
+	eng := engine.New(game, board, rules, matchLog)
+	eng.DumpCrashes(*logOpts.crash)
 	for pState := range game.Players {
-		pState.Board = board.Clone()
+		eng.Feed.Update(game, pState)
+	}
+	diedAt := trackDeaths(eng)
+
+	help = describeKeys(controls)
+	collector := stats.Attach(eng)
+	if *spectate {
+		watching = &watcher{collector: collector, follow: *follow}
+	}
+	playInTerminal(eng, controls, collector)
+
+	report := collector.Report()
+	fmt.Print(stats.Table(report))
+	if *statsFile != "" {
+		if err := writeStats(*statsFile, report); err != nil {
+			log.Errorf("Writing stats: %v", err)
+		}
+	}
+
+	if store != nil {
+		if err := rateMatch(store, eng, roster, diedAt); err != nil {
+			log.Errorf("Rating match: %v", err)
+		}
+		store.Close()
 	}
+}
 
+// playInTerminal plays the match in the terminal, then shows the results
+// until a key is pressed.
+func playInTerminal(eng *engine.Engine, controls map[keys.Key]localMove, collector *stats.Collector) {
 	log.Debugf("Initializing termbox.")
 	if err := termbox.Init(); err != nil {
 		panic(err)
 	}
+	// Deferred calls also run on panics, so the terminal is always restored.
 	defer termbox.Close()
 	w, h = termbox.Size()
 
 	log.Debugf("Initializing termbox event poller.")
 	evChan := make(chan termbox.Event)
 	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				evChan <- termbox.Event{Type: termbox.EventError, Err: fmt.Errorf("polling events: %v", r)}
+			}
+		}()
 		log.Debugf("Polling events.")
 		for {
 			ev := termbox.PollEvent()
-			if pm, ok := toPlayerMove(ev); ok {
+			if lm, ok := controls[keys.Of(ev)]; ok && ev.Type == termbox.EventKey {
 				select {
-				case inputChan <- pm:
+				case lm.input <- lm.move:
 				default:
 					log.Debugf("Dropping event '%#v', player not reading.", ev.Type)
 				}
@@ -162,46 +248,69 @@ func main() {
 	}()
 
 	log.Debugf("Drawing for first time.")
-	board.Draw(game.Players)
+	drawBoard(eng)
 
 	log.Debugf("Starting.")
 
-	MainLoop(game, board, evChan)
+	MainLoop(eng, evChan)
+
+	if !eng.Game.IsDone() {
+		winner, _ := eng.Over()
+		showResults(resultTitle(winner), collector.Report(), evChan)
+	}
 }
 
-func MainLoop(g *game.Game, board board.Board, evChan <-chan termbox.Event) {
-	for _ = range g.TurnTick.C {
-		if g.IsDone() {
-			log.Infof("Game requested to stop.")
-			return
+func MainLoop(eng *engine.Engine, evChan <-chan termbox.Event) {
+	g := eng.Game
+	drawStatus(eng)
+	for !g.IsDone() {
+		select {
+		case <-g.TurnTick.C:
+			if !g.Paused() && playTurn(eng) {
+				return
+			}
+		case ev := <-evChan:
+			if receiveEvent(eng, ev) && playTurn(eng) {
+				return
+			}
 		}
+	}
+	log.Infof("Game requested to stop.")
+}
 
-		receiveEvents(g, evChan)
-
-		g.RunSchedule(func(a scheduler.Action, turn int) error {
-			act := a.(*BomberAction)
-			log.Debugf("[%s] !!! turn %d/%d", act.name, turn, act.Duration())
-			return act.doTurn(turn)
-		})
-
-		applyPlayerMoves(g, board)
-		board.Draw(g.Players)
-		updatePlayers(g, board)
+// playTurn plays and draws a turn, and tells if the match is over.
+func playTurn(eng *engine.Engine) bool {
+	eng.Step()
+	drawBoard(eng)
+	drawStatus(eng)
+	eng.UpdatePlayers()
+
+	if winner, over := eng.Over(); over && winner != "" {
+		log.Infof("%s won. All other teams are dead.", winner)
+		return true
+	} else if over {
+		log.Infof("Draw! All players are dead.")
+		return true
+	}
+	return false
+}
 
-		alives := []player.Player{}
-		for pState, player := range g.Players {
-			if pState.Alive {
-				alives = append(alives, player)
-			}
-		}
-		if len(alives) == 1 {
-			log.Infof("%s won. All other players are dead.", alives[0].Name())
-			return
-		} else if len(alives) == 0 {
-			log.Infof("Draw! All players are dead.")
-			return
-		}
+// drawStatus tells, under the board, how fast the game goes and the keys
+// controlling it.
+func drawStatus(eng *engine.Engine) {
+	g := eng.Game
+	status := fmt.Sprintf("turn %d, %v a turn. %s", g.Turn(), g.TurnDuration(),
+		hints(keyHint(keys.Pause, "pause"), keyHint(keys.Faster, "faster"), keyHint(keys.Slower, "slower"), keyHint(keys.Help, "help")))
+	if g.Paused() {
+		status = fmt.Sprintf("turn %d, paused. %s", g.Turn(),
+			hints(keyHint(keys.Pause, "resume"), keyHint(keys.Step, "next turn"), keyHint(keys.Help, "help")))
+	}
+	if watching != nil {
+		status = hints(status, keyHint(keys.Follow, "follow"))
 	}
+	y := len(eng.Board[0])
+	drawText(0, y, fmt.Sprintf("%-*s", w, status))
+	termbox.Flush()
 }
 
 func initLocalPlayer(pState player.State) (player.Player, chan<- player.Move) {
@@ -211,154 +320,218 @@ func initLocalPlayer(pState player.State) (player.Player, chan<- player.Move) {
 }
 
 //////////////
-// Events
+// Roster
 
-func receiveEvents(g *game.Game, evChan <-chan termbox.Event) {
-	select {
-	case ev := <-evChan:
-		switch ev.Type {
-		case termbox.EventResize:
-			w, h = ev.Width, ev.Height
-		case termbox.EventError:
-			g.SetDone()
-		case termbox.EventKey:
-			doKey(g, ev.Key)
-		}
-	default:
+func loadRoster(filename string) (*config.Roster, error) {
+	if filename == "" {
+		return &defaultRoster, nil
 	}
+	fd, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+	return config.LoadRoster(fd)
 }
 
-func doKey(g *game.Game, key termbox.Key) {
-	switch key {
-	case termbox.KeyCtrlC:
-		g.SetDone()
+// loadMap reads the arena to play in, if one is given.
+func loadMap(filename string) (*board.Map, error) {
+	if filename == "" {
+		return nil, nil
 	}
+	fd, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+	return board.LoadMap(fd)
 }
 
-//////////////
-// Schedule
-
-type BomberAction struct {
-	name     string
-	duration int
-	doTurn   func(turn int) error
+// setupBoard creates the board from the arena, or generates one if there's
+// no arena.
+func setupBoard(g *game.Game, arena *board.Map, seed int64) (board.Board, *powerup.Placement, error) {
+	if seed == 0 {
+		seed = rand.Int63()
+	}
+	if arena != nil {
+		log.Infof("Rolling the rocks of the arena with seed %d.", seed)
+		b, placement := board.SetupMapBoard(g, arena, RockDensity, rand.New(rand.NewSource(seed)))
+		return b, placement, nil
+	}
+	gen, err := board.NewGenerator(*generator, RockFreeArea, RockDensity)
+	if err != nil {
+		return nil, nil, err
+	}
+	log.Infof("Generating %s arena with seed %d.", *generator, seed)
+	return board.SetupBoard(g, gen, MaxX+2, MaxY+2, seed)
 }
 
-func (a *BomberAction) Duration() int {
-	return a.duration
+// powerUpDistribution applies the power-up settings of the roster over the
+// defaults.
+func powerUpDistribution(roster *config.Roster) (powerup.Distribution, error) {
+	dist := powerup.Distribution{
+		Counts:        make(map[powerup.Kind]int),
+		Weights:       make(map[powerup.Kind]float64),
+		WeightedTotal: roster.WeightedPowerUps,
+		FairQuadrants: roster.FairPowerUps,
+		Seed:          roster.Seed,
+	}
+	if dist.Seed == 0 {
+		dist.Seed = time.Now().UnixNano()
+	}
+	for kind, n := range powerUps {
+		dist.Counts[kind] = n
+	}
+
+	for kind, n := range roster.PowerUps {
+		dist.Counts[powerup.Kind(kind)] = n
+	}
+	for kind, w := range roster.PowerUpWeights {
+		dist.Weights[powerup.Kind(kind)] = w
+	}
+	return dist, dist.Validate()
 }
 
-//////////////
-// Players
-
-func applyPlayerMoves(g *game.Game, board board.Board) {
-	for pState, player := range g.Players {
-		if pState.Alive {
-			select {
-			case m := <-player.Move():
-				movePlayer(g, board, pState, m)
-			default:
-			}
+// setupPlayers seats the roster on the spawns of the board. The returned
+// controls tell which local player every key moves, and how.
+func setupPlayers(g *game.Game, rules engine.Rules, roster *config.Roster, spawns []powerup.Spot, keysCfg *keys.Config) (map[keys.Key]localMove, error) {
+	if len(roster.Players) > len(spawns) {
+		return nil, fmt.Errorf("at most %d players can play, got %d", len(spawns), len(roster.Players))
+	}
+
+	bound, err := keysCfg.Controls(roster.Players, gameKeys)
+	if err != nil {
+		return nil, err
+	}
+	teams := roster.Teams()
+	teamColor := make(map[string]termbox.Attribute)
+	for _, e := range roster.Players {
+		if _, ok := teamColor[e.Team]; e.Team != "" && !ok {
+			teamColor[e.Team] = objects.TeamColors[len(teamColor)%len(objects.TeamColors)]
 		}
 	}
-}
 
-func updatePlayers(game *game.Game, board board.Board) {
-	for pState, player := range game.Players {
-		pState.Board = board.Clone()
-		pState.Turn = game.Turn()
-		select {
-		case player.Update() <- *pState:
+	controls := make(map[keys.Key]localMove)
+	g.Players = make(map[*player.State]player.Player, len(roster.Players))
+	for i, e := range roster.Players {
+		pState := rules.NewState(e.Name, e.Team, spawns[i].X, spawns[i].Y)
+		pState.KeyframeEvery = e.KeyframeEvery
+		pState.GameObject = &objects.TboxPlayer{Name: e.Name, Bg: teamColor[e.Team]}
+		for _, mate := range teams[e.Team] {
+			if mate != e.Name {
+				pState.Teammates = append(pState.Teammates, mate)
+			}
+		}
+
+		var p player.Player
+		switch e.Kind {
+		case "local":
+			var input chan<- player.Move
+			p, input = initLocalPlayer(*pState)
+			for k, c := range bound {
+				if c.Player == e.Name {
+					controls[k] = localMove{name: e.Name, input: input, move: c.Move}
+				}
+			}
+		case "tcp":
+			p = bombertcp.NewTcpPlayer(*pState, e.Addr, log)
+		case "remote":
+			// The client draws the whole board it's sent every turn.
+			pState.KeyframeEvery = 0
+			fmt.Fprintf(os.Stderr, "Waiting for %s to connect on %s.\n", e.Name, e.Addr)
+			remotePlayer, err := remote.Accept(*pState, e.Addr)
+			if err != nil {
+				return nil, fmt.Errorf("player %q: %v", e.Name, err)
+			}
+			log.Infof("%s connected from %s.", e.Name, remotePlayer.Addr())
+			p = remotePlayer
+		case "random":
+			p = ai.NewRandomPlayer(*pState, e.Seed)
+		case "wandering":
+			p = ai.NewWanderingPlayer(*pState, e.Seed)
+		case "immobile":
+			p = ai.NewImmobilePlayer(*pState)
 		default:
+			return nil, fmt.Errorf("player %q: unknown kind %q", e.Name, e.Kind)
 		}
+		g.Players[pState] = p
 	}
+	return controls, nil
 }
 
-func toPlayerMove(ev termbox.Event) (player.Move, bool) {
-	if ev.Type != termbox.EventKey {
-		return player.Move(""), false
-	}
+//////////////
+// Events
 
-	switch ev.Key {
-	case termbox.KeyArrowUp:
-		return player.Up, true
-	case termbox.KeyArrowDown:
-		return player.Down, true
-	case termbox.KeyArrowLeft:
-		return player.Left, true
-	case termbox.KeyArrowRight:
-		return player.Right, true
-	case termbox.KeySpace:
-		return player.PutBomb, true
+// receiveEvent handles an event of the terminal, and tells if a turn must be
+// played at once.
+func receiveEvent(eng *engine.Engine, ev termbox.Event) bool {
+	switch ev.Type {
+	case termbox.EventResize:
+		w, h = ev.Width, ev.Height
+	case termbox.EventError:
+		log.Errorf("Terminal failed: %v", ev.Err)
+		eng.Game.SetDone()
+	case termbox.EventKey:
+		return doKey(eng, ev)
 	}
-
-	return player.Move(""), false
+	return false
 }
 
-func movePlayer(g *game.Game, board board.Board, pState *player.State, action player.Move) {
-	nextX, nextY := pState.X, pState.Y
+const (
+	minTurnDuration = 25 * time.Millisecond
+	maxTurnDuration = 2 * time.Second
+)
+
+// doKey controls the game as the keys are bound: quit, pause, step a turn
+// while paused, change the speed, follow another player or show help. It
+// tells if a turn must be played at once.
+func doKey(eng *engine.Engine, ev termbox.Event) bool {
+	g := eng.Game
+	action := gameKeys[keys.Of(ev)]
+	if helpShown && action != keys.Quit {
+		hideHelp(eng)
+		return false
+	}
 	switch action {
-	case player.Up:
-		nextY--
-	case player.Down:
-		nextY++
-	case player.Left:
-		nextX--
-	case player.Right:
-		nextX++
-	case player.PutBomb:
-		placeBomb(board, g, pState)
-	}
-
-	if !board.Traversable(nextX, nextY) {
-		return
-	}
-
-	doMove := func(turn int) error {
-		if board[nextX][nextY].Top() == objects.Flame {
-			pState.Alive = false
-			log.Infof("[%s] Died moving into flame.", pState.Name)
-			cell := board[pState.X][pState.Y]
-			if !cell.Remove(pState.GameObject) {
-				log.Panicf("[%s] player not found at (%d, %d), cell=%#v",
-					pState.Name, pState.X, pState.Y, cell)
-			}
-			return nil
+	case keys.Quit:
+		g.SetDone()
+		return false
+	case keys.Help:
+		showHelp(eng)
+		return false
+	case keys.Follow:
+		if watching != nil {
+			snap := eng.Snapshot()
+			watching.follow = nextFollow(snap.Players, watching.follow)
+			watching.draw(eng)
 		}
-
-		pState.LastX, pState.LastY = pState.X, pState.Y
-		pState.X, pState.Y = nextX, nextY
-
-		pickPowerUps(board, pState, nextX, nextY)
-
-		cell := board[pState.LastX][pState.LastY]
-		if !cell.Remove(pState.GameObject) {
-			log.Panicf("[%s] player not found at (%d, %d), cell=%#v",
-				pState.Name, pState.X, pState.Y, cell)
+		return false
+	case keys.Pause:
+		if g.Paused() {
+			g.Resume()
+		} else {
+			g.Pause()
 		}
-		board[nextX][nextY].Push(pState.GameObject)
-
-		return nil
+	case keys.Step:
+		if g.Paused() {
+			return true
+		}
+	case keys.Faster:
+		setTurnDuration(eng, g.TurnDuration()/2)
+	case keys.Slower:
+		setTurnDuration(eng, g.TurnDuration()*2)
 	}
-
-	g.Schedule.Register(&BomberAction{
-		name:     fmt.Sprintf("%s.moving(%#v)", pState.Name, action),
-		duration: 1,
-		doTurn:   doMove,
-	}, 1)
-
+	drawStatus(eng)
+	return false
 }
 
-func pickPowerUps(board board.Board, pState *player.State, x, y int) {
-	c := board[x][y]
-	switch c.Top() {
-	case objects.BombPU:
-		pState.MaxBomb++
-		c.Pop()
-		log.Infof("[%s] Powerup! Max bombs: %d", pState.Name, pState.MaxBomb)
-	case objects.RadiusPU:
-		pState.MaxRadius++
-		c.Pop()
-		log.Infof("[%s] Powerup! Max radius: %d", pState.Name, pState.MaxRadius)
+func setTurnDuration(eng *engine.Engine, d time.Duration) {
+	switch {
+	case d < minTurnDuration:
+		d = minTurnDuration
+	case d > maxTurnDuration:
+		d = maxTurnDuration
 	}
+	eng.Game.SetTurnDuration(d)
+	eng.SetTurnDuration(d)
 }
@@ -4,10 +4,87 @@ type GameObject interface {
 	String() string
 	Draw(x, y int)
 	Traversable() bool
+	Kind() Kind
 }
 
+// Kind is the type of a game object, as seen by players.
+type Kind string
+
+const (
+	Ground  = Kind("ground")
+	Wall    = Kind("wall")
+	Rock    = Kind("rock")
+	Bomb    = Kind("bomb")
+	Flame   = Kind("flame")
+	Player  = Kind("player")
+	PowerUp = Kind("powerup")
+	// Teleporters send players stepping on them to the next teleporter of
+	// the board, in reading order.
+	Teleporter = Kind("teleporter")
+	// Unknown cells are out of sight.
+	Unknown = Kind("unknown")
+)
+
+// Describer is implemented by objects with more to tell players than their
+// kind, like the owner of a bomb or the time left before it explodes.
+type Describer interface {
+	Describe(l *Layer, now int)
+}
+
+// Exported is what players see of a cell.
 type Exported struct {
+	// Name of the object on top.
 	Name string
+	// Layers visible from above, starting with the top one. Walls and rocks
+	// hide what's under them.
+	Layers []Layer
+}
+
+// Layer is an object of a cell, as seen by players.
+type Layer struct {
+	Kind Kind
+	Name string
+	// PowerUp names the kind of power-up, for power-ups.
+	PowerUp string
+	// Owner is the name of the player whose bomb or flame this is.
+	Owner  string
+	Radius int
+	// TurnsLeft before a bomb explodes or a flame clears.
+	TurnsLeft int
+}
+
+// Top is the layer on top of the cell.
+func (e *Exported) Top() Layer {
+	return e.Layers[0]
+}
+
+// Timed tells if one of the visible layers is a bomb or a flame, whose
+// TurnsLeft changes every turn.
+func (e *Exported) Timed() bool {
+	return e.Has(Bomb) || e.Has(Flame)
+}
+
+// Equal tells if two exported cells look the same.
+func (e *Exported) Equal(o *Exported) bool {
+	if e.Name != o.Name || len(e.Layers) != len(o.Layers) {
+		return false
+	}
+	for i := range e.Layers {
+		if e.Layers[i] != o.Layers[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Has tells if one of the visible layers is of the given kind.
+func (e *Exported) Has(k Kind) bool {
+	for _, l := range e.Layers {
+		if l.Kind == k {
+			return true
+		}
+	}
+	return false
 }
 
 // Cell is a cell on the board. A cell can have many z layers.
@@ -16,6 +93,8 @@ type Cell struct {
 	zLayers []GameObject
 	X       int
 	Y       int
+	// version changes every time the layers do.
+	version int
 }
 
 // NewCell creates a cell with base as z layer 0.
@@ -39,6 +118,7 @@ func (c *Cell) Top() GameObject {
 // Push adds an object to the top of the z layers.
 func (c *Cell) Push(o GameObject) {
 	c.zLayers = append(c.zLayers, o)
+	c.version++
 }
 
 // Pop returns the top object.  It will remove the object from the layers unless
@@ -50,6 +130,7 @@ func (c *Cell) Pop() (GameObject, bool) {
 	var pop GameObject
 	pop = c.zLayers[len(c.zLayers)-1]
 	c.zLayers = c.zLayers[:len(c.zLayers)-1]
+	c.version++
 	return pop, true
 }
 
@@ -71,6 +152,7 @@ func (c *Cell) RemoveLayer(z int) GameObject {
 
 	// z has at least 1 element
 	c.zLayers = c.zLayers[:len(c.zLayers)-1]
+	c.version++
 	return removed
 }
 
@@ -98,13 +180,40 @@ func (c *Cell) Layer(z int) GameObject {
 	return c.zLayers[z-1]
 }
 
+// Version changes every time the layers of the cell do.
+func (c *Cell) Version() int {
+	return c.version
+}
+
 // Depth gives the depth of the z layer, including the base layer.
 func (c *Cell) Depth() int {
 	return 1 + len(c.zLayers)
 }
 
-func (c *Cell) Export() *Exported {
+// ExportUnknown describes a cell out of sight.
+func ExportUnknown() *Exported {
 	return &Exported{
-		Name: c.Top().String(),
+		Name:   "Unknown",
+		Layers: []Layer{{Kind: Unknown, Name: "Unknown"}},
+	}
+}
+
+// Export describes the cell as seen by players at turn now.
+func (c *Cell) Export(now int) *Exported {
+	e := &Exported{
+		Name:   c.Top().String(),
+		Layers: make([]Layer, 0, c.Depth()),
+	}
+	for z := c.Depth() - 1; z >= 0; z-- {
+		obj := c.Layer(z)
+		l := Layer{Kind: obj.Kind(), Name: obj.String()}
+		if d, ok := obj.(Describer); ok {
+			d.Describe(&l, now)
+		}
+		e.Layers = append(e.Layers, l)
+		if l.Kind == Wall || l.Kind == Rock {
+			break
+		}
 	}
+	return e
 }
@@ -0,0 +1,50 @@
+package cell_test
+
+import (
+	"github.com/aybabtme/bomberman/cell"
+	"testing"
+)
+
+type obj struct {
+	kind      cell.Kind
+	explodeAt int
+}
+
+func (o *obj) String() string    { return string(o.kind) }
+func (o *obj) Draw(x, y int)     {}
+func (o *obj) Traversable() bool { return true }
+func (o *obj) Kind() cell.Kind   { return o.kind }
+func (o *obj) Describe(l *cell.Layer, now int) {
+	if o.explodeAt != 0 {
+		l.TurnsLeft = o.explodeAt - now
+	}
+}
+
+func TestExportHidesUnderRocks(t *testing.T) {
+	c := cell.NewCell(&obj{kind: cell.Ground}, 0, 0)
+	c.Push(&obj{kind: cell.PowerUp})
+	c.Push(&obj{kind: cell.Rock})
+	c.Push(&obj{kind: cell.Flame})
+
+	e := c.Export(0)
+	if len(e.Layers) != 2 || e.Layers[0].Kind != cell.Flame || e.Layers[1].Kind != cell.Rock {
+		t.Fatalf("want flame over rock, got %+v", e.Layers)
+	}
+	if e.Has(cell.PowerUp) {
+		t.Errorf("power-up under the rock should be hidden")
+	}
+}
+
+func TestExportDescribesLayers(t *testing.T) {
+	c := cell.NewCell(&obj{kind: cell.Ground}, 0, 0)
+	c.Push(&obj{kind: cell.Player})
+	c.Push(&obj{kind: cell.Bomb, explodeAt: 12})
+
+	e := c.Export(9)
+	if e.Top().Kind != cell.Bomb || e.Top().TurnsLeft != 3 {
+		t.Errorf("want bomb exploding in 3 turns on top, got %+v", e.Top())
+	}
+	if len(e.Layers) != 3 || !e.Has(cell.Player) || !e.Has(cell.Ground) {
+		t.Errorf("want bomb, player and ground, got %+v", e.Layers)
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/aybabtme/bomberman/keys"
+	"github.com/aybabtme/bomberman/player"
+	"github.com/nsf/termbox-go"
+	"io"
+	"net"
+	"strings"
+)
+
+// runClient plays a remote seat of a match hosted elsewhere: the board is
+// drawn as the server sends it, and the keys are sent back as moves.
+func runClient(args []string) {
+	flags := flag.NewFlagSet("client", flag.ExitOnError)
+	logOpts := logFlags(flags, "info")
+	addr := flags.String("addr", "localhost:40000", "address the remote seat listens on")
+	keysFile := flags.String("keys", "", "JSON file binding keys to the controls of the game")
+	preset := flags.String("preset", "arrows", "keys to play with: "+strings.Join(presetNames(), ", "))
+	flags.Parse(args)
+	logOpts.open()
+
+	if _, err := loadKeys(*keysFile); err != nil {
+		log.Fatalf("Loading keys: %v", err)
+	}
+	moves, err := keys.Binding{Preset: *preset}.Moves()
+	if err != nil {
+		log.Fatalf("Loading keys: %v", err)
+	}
+
+	conn, err := net.Dial("tcp", *addr)
+	if err != nil {
+		log.Fatalf("Connecting: %v", err)
+	}
+	defer conn.Close()
+
+	if err := termbox.Init(); err != nil {
+		panic(err)
+	}
+	defer termbox.Close()
+	w, h = termbox.Size()
+
+	states := make(chan player.State)
+	gone := make(chan error, 1)
+	go func() {
+		dec := json.NewDecoder(conn)
+		for {
+			state := player.State{}
+			if err := dec.Decode(&state); err != nil {
+				gone <- err
+				return
+			}
+			states <- state
+		}
+	}()
+	evChan := make(chan termbox.Event)
+	go func() {
+		for {
+			evChan <- termbox.PollEvent()
+		}
+	}()
+
+	termbox.Clear(termbox.ColorDefault, termbox.ColorDefault)
+	drawText(0, 0, "Waiting for the match to start.")
+	termbox.Flush()
+	y := 0
+	for {
+		select {
+		case state := <-states:
+			drawClient(state)
+			y = len(state.Board[0])
+		case err := <-gone:
+			msg := "The server is gone, the match is over."
+			if err != io.EOF {
+				msg = fmt.Sprintf("The server is gone: %v", err)
+			}
+			drawText(0, y, fmt.Sprintf("%-*s", w, msg+" Press a key to quit."))
+			termbox.Flush()
+			for ev := range evChan {
+				if ev.Type == termbox.EventKey || ev.Type == termbox.EventError {
+					return
+				}
+			}
+		case ev := <-evChan:
+			switch {
+			case ev.Type == termbox.EventResize:
+				w, h = ev.Width, ev.Height
+			case ev.Type == termbox.EventError:
+				log.Errorf("Terminal failed: %v", ev.Err)
+				return
+			case ev.Type != termbox.EventKey:
+			case gameKeys[keys.Of(ev)] == keys.Quit:
+				return
+			}
+			if move, ok := moves[keys.Of(ev)]; ok && ev.Type == termbox.EventKey {
+				if _, err := fmt.Fprintln(conn, move); err != nil {
+					log.Errorf("Sending move: %v", err)
+				}
+			}
+		}
+	}
+}
+
+// drawClient draws the board as the player sees it, and how they're doing.
+func drawClient(state player.State) {
+	if len(state.Board) == 0 {
+		return
+	}
+	v := &view{Board: state.Board, Players: []player.State{state}, Follow: state.Name}
+	v.draw()
+	status := fmt.Sprintf("turn %d. %s", state.Turn, keyHint(keys.Quit, "quit"))
+	if !state.Alive {
+		status = fmt.Sprintf("turn %d. You died. %s", state.Turn, keyHint(keys.Quit, "quit"))
+	}
+	drawText(0, len(state.Board[0]), fmt.Sprintf("%-*s", w, status))
+	termbox.Flush()
+}
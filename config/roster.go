@@ -0,0 +1,117 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Roster describes who plays in a match and under which rules.
+type Roster struct {
+	// FriendlyFire lets blasts hurt members of the bomber's own team.
+	FriendlyFire bool `json:"friendlyFire"`
+	// VisionRadius limits how far players see, in cells. Zero sees the
+	// whole board.
+	VisionRadius int `json:"visionRadius,omitempty"`
+	// LineOfSight hides what's behind walls and rocks from players.
+	LineOfSight bool `json:"lineOfSight,omitempty"`
+	// PowerUps overrides how many of each kind of power-up are hidden under
+	// rocks, by kind: "bomb", "radius", "kick", "remote", "pierce", "speed",
+	// "shield" or "skull".
+	PowerUps map[string]int `json:"powerUps,omitempty"`
+	// PowerUpWeights splits WeightedPowerUps more power-ups among kinds, in
+	// proportion to their weight.
+	PowerUpWeights   map[string]float64 `json:"powerUpWeights,omitempty"`
+	WeightedPowerUps int                `json:"weightedPowerUps,omitempty"`
+	// FairPowerUps hides as many of each power-up in every quadrant of the
+	// board.
+	FairPowerUps bool `json:"fairPowerUps,omitempty"`
+	// Seed makes the placement of power-ups reproducible. Zero picks a
+	// random seed.
+	Seed    int64   `json:"seed,omitempty"`
+	Players []Entry `json:"players"`
+}
+
+// Entry is a single seat in a roster.
+type Entry struct {
+	Name string `json:"name"`
+	// Kind is the type of player sitting there: "local", "tcp", "remote",
+	// "random", "wandering", "immobile" or, in headless matches, "process".
+	Kind string `json:"kind"`
+	// Team is optional. Players without a team are on their own.
+	Team string `json:"team,omitempty"`
+	// Keys bind keys to the moves of a local player, like {"w": "up",
+	// "tab": "bomb"}. Local players without keys get the default ones.
+	Keys map[string]string `json:"keys,omitempty"`
+	// Addr is where a network player listens, for kinds that need one.
+	Addr string `json:"addr,omitempty"`
+	// Command runs a process player: the program, then its arguments.
+	Command []string `json:"command,omitempty"`
+	// Seed drives the randomness of AI players.
+	Seed int64 `json:"seed,omitempty"`
+	// KeyframeEvery, when positive, sends the player what changed on the
+	// board every turn, and the full board only every that many turns.
+	KeyframeEvery int `json:"keyframeEvery,omitempty"`
+}
+
+// LoadRoster decodes a JSON roster and validates it.
+func LoadRoster(r io.Reader) (*Roster, error) {
+	roster := &Roster{}
+	if err := json.NewDecoder(r).Decode(roster); err != nil {
+		return nil, fmt.Errorf("decoding roster, %v", err)
+	}
+	return roster, roster.Validate()
+}
+
+// Validate checks that the roster can be used to start a match.
+func (r *Roster) Validate() error {
+	if len(r.Players) < 2 {
+		return fmt.Errorf("roster needs at least 2 players, got %d", len(r.Players))
+	}
+	names := make(map[string]bool, len(r.Players))
+	for i, e := range r.Players {
+		if len([]rune(e.Name)) < 2 {
+			return fmt.Errorf("player %d: name %q must have at least 2 characters", i, e.Name)
+		}
+		if names[e.Name] {
+			return fmt.Errorf("player %d: duplicate name %q", i, e.Name)
+		}
+		names[e.Name] = true
+		if e.Kind == "" {
+			return fmt.Errorf("player %q: missing kind", e.Name)
+		}
+		if len(e.Keys) > 0 && e.Kind != "local" {
+			return fmt.Errorf("player %q: only local players have keys", e.Name)
+		}
+	}
+	return CheckTeams(r.Players)
+}
+
+// CheckTeams makes sure no player is named like a team. Players without a team
+// fight on a side named after them, which would be the team's.
+func CheckTeams(players []Entry) error {
+	teams := make(map[string]bool)
+	for _, e := range players {
+		if e.Team != "" {
+			teams[e.Team] = true
+		}
+	}
+	for _, e := range players {
+		if teams[e.Name] {
+			return fmt.Errorf("player %q: named like a team", e.Name)
+		}
+	}
+	return nil
+}
+
+// Teams lists the members of each team, by team name. Players without a team
+// are not listed.
+func (r *Roster) Teams() map[string][]string {
+	teams := make(map[string][]string)
+	for _, e := range r.Players {
+		if e.Team != "" {
+			teams[e.Team] = append(teams[e.Team], e.Name)
+		}
+	}
+	return teams
+}
@@ -0,0 +1,69 @@
+package config_test
+
+import (
+	"github.com/aybabtme/bomberman/config"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		players []config.Entry
+		err     string
+	}{
+		{"valid", []config.Entry{
+			{Name: "p1", Kind: "local", Keys: map[string]string{"w": "up"}},
+			{Name: "p2", Kind: "random", Team: "red"},
+		}, ""},
+		{"alone", []config.Entry{{Name: "p1", Kind: "local"}}, "at least 2 players"},
+		{"short name", []config.Entry{
+			{Name: "p", Kind: "local"},
+			{Name: "p2", Kind: "random"},
+		}, "at least 2 characters"},
+		{"duplicate name", []config.Entry{
+			{Name: "p1", Kind: "local"},
+			{Name: "p1", Kind: "random"},
+		}, `duplicate name "p1"`},
+		{"no kind", []config.Entry{
+			{Name: "p1", Kind: "local"},
+			{Name: "p2"},
+		}, "missing kind"},
+		{"keys of a bot", []config.Entry{
+			{Name: "p1", Kind: "local"},
+			{Name: "p2", Kind: "random", Keys: map[string]string{"w": "up"}},
+		}, "only local players have keys"},
+		{"solo player named like a team", []config.Entry{
+			{Name: "red", Kind: "local"},
+			{Name: "p2", Kind: "random", Team: "red"},
+			{Name: "p3", Kind: "random", Team: "red"},
+		}, `player "red": named like a team`},
+	}
+	for _, tt := range tests {
+		err := (&config.Roster{Players: tt.players}).Validate()
+		switch {
+		case tt.err == "" && err != nil:
+			t.Errorf("%s: want valid, got %v", tt.name, err)
+		case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+			t.Errorf("%s: want error containing %q, got %v", tt.name, tt.err, err)
+		}
+	}
+}
+
+func TestLoadRosterTeams(t *testing.T) {
+	roster, err := config.LoadRoster(strings.NewReader(`{"friendlyFire": true, "players": [
+		{"name": "red1", "kind": "local", "team": "red"},
+		{"name": "solo", "kind": "random"},
+		{"name": "red2", "kind": "wandering", "team": "red"}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !roster.FriendlyFire {
+		t.Errorf("want friendly fire on")
+	}
+	teams := roster.Teams()
+	if len(teams) != 1 || strings.Join(teams["red"], ",") != "red1,red2" {
+		t.Errorf("want red1 and red2 on red, and solo on no team, got %v", teams)
+	}
+}
@@ -0,0 +1,233 @@
+package engine
+
+import (
+	"fmt"
+	"github.com/aybabtme/bomberman/cell"
+	"github.com/aybabtme/bomberman/event"
+	"github.com/aybabtme/bomberman/game"
+	"github.com/aybabtme/bomberman/objects"
+	"github.com/aybabtme/bomberman/player"
+	"github.com/aybabtme/bomberman/powerup"
+)
+
+// Bombs!
+func (e *Engine) placeBomb(placerState *player.State) {
+	board, game := e.Board, e.Game
+	placer := game.Players[placerState]
+	log := e.logFor(placerState)
+	log.Debugf("Attempting to place bomb (%d/%d).", placerState.Bombs, placerState.MaxBomb)
+
+	switch {
+	case placerState.Bombs > placerState.MaxBomb:
+		log.Panicf("Has %d/%d bombs.", placerState.Bombs, placerState.MaxBomb)
+	case placerState.Bombs == placerState.MaxBomb:
+		log.Debugf("Failed.")
+		return
+	}
+
+	placerState.Bombs++
+	x, y := placerState.X, placerState.Y
+	// radius is snapshot'd at this point in time
+	radius := placerState.MaxRadius
+
+	doPlaceBomb := func(turn int) error {
+		bomb := game.PlaceBomb(placerState, x, y, radius, e.Rules.TurnsToExplode)
+		board[x][y].Push(bomb)
+		e.Events.Publish(event.BombPlaced{
+			Turn:       e.now(),
+			Owner:      placerState.Name,
+			X:          x,
+			Y:          y,
+			Radius:     radius,
+			ExplodesAt: bomb.ExplodesAt,
+		})
+
+		e.logFor(placerState).Debugf("Registering bomb explosion.")
+		game.Schedule.Register(&BomberAction{
+			name:     fmt.Sprintf("%s.doExplosion", placer.Name()),
+			duration: 1,
+			doTurn: func(turn int) error {
+				e.explodeBomb(bomb)
+				return nil
+			},
+		}, e.Rules.TurnsToExplode)
+		return nil
+	}
+
+	game.Schedule.Register(&BomberAction{
+		name:     fmt.Sprintf("%s.placeBomb", placer.Name()),
+		duration: 1,
+		doTurn:   doPlaceBomb,
+	}, 1)
+
+}
+
+// explodeBomb blows a bomb up where it currently lies, unless it already
+// exploded, and schedules its flameout and the replenishment of its owner.
+func (e *Engine) explodeBomb(bomb *game.Bomb) {
+	if bomb.Exploded {
+		return
+	}
+	bomb.Exploded = true
+	e.Game.RemoveBomb(bomb)
+
+	owner := bomb.Owner
+	x, y := bomb.X, bomb.Y
+	e.logFor(owner).Debugf("Bomb exploding.")
+
+	flame := e.Game.NewFlame(bomb, e.Rules.TurnsToFlamout)
+	e.Events.Publish(event.BombExploded{
+		Turn:   e.now(),
+		Owner:  owner.Name,
+		X:      x,
+		Y:      y,
+		Radius: bomb.Radius,
+	})
+	e.explode(bomb, flame)
+
+	replenishBomb := func(turn int) error {
+		if owner.Bombs > 0 {
+			owner.Bombs--
+		} else {
+			e.logFor(owner).Errorf("Too many bombs, %d (max %d).", owner.Bombs, owner.MaxBomb)
+		}
+		return nil
+	}
+
+	doFlameout := func(turn int) error {
+		e.logFor(owner).Debugf("Bomb flameout.")
+		e.removeFlame(flame, x, y, bomb.Radius, bomb.Pierce)
+		e.Events.Publish(event.FlameCleared{
+			Turn:   e.now(),
+			Owner:  owner.Name,
+			X:      x,
+			Y:      y,
+			Radius: bomb.Radius,
+		})
+		return nil
+	}
+
+	e.logFor(owner).Debugf("Registering flameout.")
+	e.Game.Schedule.Register(&BomberAction{
+		name:     fmt.Sprintf("%s.doFlameout", owner.Name),
+		duration: 1,
+		doTurn:   doFlameout,
+	}, e.Rules.TurnsToFlamout)
+
+	e.logFor(owner).Debugf("Registering bomb replenishment.")
+	e.Game.Schedule.Register(&BomberAction{
+		name:     fmt.Sprintf("%s.replenishBomb", owner.Name),
+		duration: 1,
+		doTurn:   replenishBomb,
+	}, e.Rules.TurnsToReplenish)
+}
+
+// detonate sets off all the bombs of a player holding a remote.
+func (e *Engine) detonate(pState *player.State) {
+	if !pState.CanRemote {
+		return
+	}
+	e.Game.Schedule.Register(&BomberAction{
+		name:     fmt.Sprintf("%s.detonate", pState.Name),
+		duration: 1,
+		doTurn: func(turn int) error {
+			for _, bomb := range e.Game.BombsOf(pState) {
+				e.explodeBomb(bomb)
+			}
+			return nil
+		},
+	}, 1)
+}
+
+// kickBomb slides a bomb one cell per turn in the direction (dx, dy), until
+// something other than the ground stops it.
+func (e *Engine) kickBomb(bomb *game.Bomb, dx, dy int) {
+	board := e.Board
+	var slide func(turn int) error
+	slide = func(turn int) error {
+		if bomb.Exploded {
+			return nil
+		}
+		nextX, nextY := bomb.X+dx, bomb.Y+dy
+		if board[nextX][nextY].Top() != objects.Ground {
+			return nil
+		}
+		board[bomb.X][bomb.Y].Remove(bomb)
+		bomb.X, bomb.Y = nextX, nextY
+		board[nextX][nextY].Push(bomb)
+
+		e.Game.Schedule.Register(&BomberAction{
+			name:     fmt.Sprintf("%s.bombSliding", bomb.Owner.Name),
+			duration: 1,
+			doTurn:   slide,
+		}, 1)
+		return nil
+	}
+	slide(0)
+}
+
+func (e *Engine) explode(bomb *game.Bomb, flame *game.Flame) {
+	board, game := e.Board, e.Game
+	board[bomb.X][bomb.Y].Remove(bomb)
+	board.AsCross(bomb.X, bomb.Y, bomb.Radius, func(c *cell.Cell) bool {
+
+		for playerState := range game.Players {
+			x, y := playerState.X, playerState.Y
+			if playerState.Alive && c.X == x && c.Y == y && game.Hurts(bomb.Owner, playerState) {
+				if e.shielded(playerState) {
+					continue
+				}
+				e.logFor(playerState).Infof("Dying in explosion.")
+				playerState.Alive = false
+				c.Remove(playerState.GameObject)
+				e.died(playerState, bomb.Owner)
+			}
+		}
+
+		top := c.Top()
+		switch {
+		case top == objects.Wall:
+		case top == objects.Rock:
+			c.Push(flame)
+			return bomb.Pierce
+		case powerup.Is(top): // Explosions kill PowerUps
+			c.Pop()
+			c.Push(flame)
+			return false
+		default:
+			c.Push(flame)
+			return true
+		}
+
+		if c.Top() != objects.Wall {
+			c.Push(flame)
+		}
+
+		return true
+	})
+}
+
+// shielded uses up the shield of a player about to be hurt, if they have one.
+func (e *Engine) shielded(pState *player.State) bool {
+	if pState.Shield == 0 {
+		return false
+	}
+	pState.Shield--
+	e.logFor(pState).Infof("Shield absorbed the hit, %d left.", pState.Shield)
+	return true
+}
+
+func (e *Engine) removeFlame(flame *game.Flame, x, y, radius int, pierce bool) {
+	e.Board.AsCross(x, y, radius, func(c *cell.Cell) bool {
+		c.Remove(flame)
+		if c.Top() == objects.Rock {
+			c.Pop()
+			e.Events.Publish(event.RockDestroyed{Turn: e.now(), Owner: flame.Owner.Name, X: c.X, Y: c.Y})
+			if pu, ok := powerup.Find(c.Top()); ok {
+				e.Events.Publish(event.PowerUpSpawned{Turn: e.now(), PowerUp: string(pu.Kind), X: c.X, Y: c.Y})
+			}
+			return pierce
+		}
+		return true
+	})
+}
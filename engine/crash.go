@@ -0,0 +1,127 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/aybabtme/bomberman/cell"
+	"github.com/aybabtme/bomberman/logger"
+	"github.com/aybabtme/bomberman/player"
+	"io/ioutil"
+	"path/filepath"
+	"runtime/debug"
+	"sort"
+	"time"
+)
+
+// Snapshot is the whole state of a game at a turn.
+type Snapshot struct {
+	Turn    int                `json:"turn"`
+	Players []player.State     `json:"players"`
+	Bombs   []BombSnapshot     `json:"bombs"`
+	Board   [][]*cell.Exported `json:"board"`
+}
+
+// BombSnapshot is a bomb ticking on the board.
+type BombSnapshot struct {
+	Owner      string `json:"owner"`
+	X          int    `json:"x"`
+	Y          int    `json:"y"`
+	Radius     int    `json:"radius"`
+	Pierce     bool   `json:"pierce"`
+	ExplodesAt int    `json:"explodes_at"`
+}
+
+// Snapshot captures the state of the game, players sorted by name.
+func (e *Engine) Snapshot() *Snapshot {
+	now := e.now()
+	s := &Snapshot{Turn: now, Board: e.Board.Clone(now)}
+	for pState := range e.Game.Players {
+		p := *pState
+		// What players look like on the terminal isn't state.
+		p.Board, p.Delta, p.GameObject = nil, nil, nil
+		s.Players = append(s.Players, p)
+	}
+	sort.Slice(s.Players, func(i, j int) bool { return s.Players[i].Name < s.Players[j].Name })
+	for _, b := range e.Game.Bombs {
+		s.Bombs = append(s.Bombs, BombSnapshot{
+			Owner:      b.Owner.Name,
+			X:          b.X,
+			Y:          b.Y,
+			Radius:     b.Radius,
+			Pierce:     b.Pierce,
+			ExplodesAt: b.ExplodesAt,
+		})
+	}
+	return s
+}
+
+// Crash is written to a file when the engine panics.
+type Crash struct {
+	Time     time.Time `json:"time"`
+	Panic    string    `json:"panic"`
+	Stack    string    `json:"stack"`
+	Snapshot *Snapshot `json:"snapshot,omitempty"`
+	// Log holds the last lines logged, whatever the log level.
+	Log []string `json:"log,omitempty"`
+}
+
+// crashLines are how many of the last lines logged a crash dump holds.
+const crashLines = 5000
+
+// DumpCrashes has the engine write a crash dump in dir if it panics, with the
+// last lines it logged. Every line is formatted to be kept, whatever the log
+// level, so it's only done when asked for.
+func (e *Engine) DumpCrashes(dir string) {
+	e.crashDir = dir
+	if dir != "" && e.recent == nil {
+		e.recent = logger.NewRing(crashLines)
+		e.log = e.log.Capture(e.recent)
+	}
+}
+
+// dumpOnPanic writes a crash dump when the engine panics, then panics again.
+// It must be deferred.
+func (e *Engine) dumpOnPanic() {
+	if r := recover(); r != nil {
+		e.crashed(r, debug.Stack())
+		panic(r)
+	}
+}
+
+// crashed logs a panic, and writes a crash dump if asked to.
+func (e *Engine) crashed(r interface{}, stack []byte) {
+	if e.crashDir == "" {
+		e.log.Errorf("Engine panicked: %v.", r)
+		return
+	}
+	filename, err := e.dump(r, stack)
+	if err != nil {
+		e.log.Errorf("Engine panicked: %v. Writing crash dump: %v", r, err)
+		return
+	}
+	e.log.Errorf("Engine panicked: %v, crash dump in %s.", r, filename)
+}
+
+func (e *Engine) dump(r interface{}, stack []byte) (string, error) {
+	crash := Crash{Time: time.Now(), Panic: fmt.Sprint(r), Stack: string(stack)}
+	if e.recent != nil {
+		crash.Log = e.recent.Lines()
+	}
+	// The game may be what's broken; get what can be of it.
+	func() {
+		defer func() {
+			if err := recover(); err != nil {
+				crash.Snapshot = nil
+				crash.Log = append(crash.Log, fmt.Sprintf("taking snapshot: %v", err))
+			}
+		}()
+		crash.Snapshot = e.Snapshot()
+	}()
+
+	data, err := json.MarshalIndent(crash, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	filename := filepath.Join(e.crashDir, fmt.Sprintf("crash-%s.json", crash.Time.Format("20060102-150405.000000000")))
+	return filename, ioutil.WriteFile(filename, data, 0644)
+}
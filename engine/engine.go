@@ -0,0 +1,224 @@
+// Package engine plays the turns of a match: it runs the scheduled actions,
+// applies the moves of players, and tells them what the board looks like.
+package engine
+
+import (
+	"fmt"
+	"github.com/aybabtme/bomberman/board"
+	"github.com/aybabtme/bomberman/cell"
+	"github.com/aybabtme/bomberman/event"
+	"github.com/aybabtme/bomberman/game"
+	"github.com/aybabtme/bomberman/logger"
+	"github.com/aybabtme/bomberman/objects"
+	"github.com/aybabtme/bomberman/player"
+	"github.com/aybabtme/bomberman/scheduler"
+	"runtime/debug"
+	"time"
+)
+
+// Rules are the timings of a match and the stats players start with.
+type Rules struct {
+	TurnDuration time.Duration
+
+	TurnsToFlamout   int
+	TurnsToReplenish int
+	TurnsToExplode   int
+
+	DefaultMaxBomb    int
+	DefaultBombRadius int
+	DefaultSpeed      int
+}
+
+// DefaultRules are the rules of a classic match.
+var DefaultRules = Rules{
+	TurnDuration: time.Millisecond * 200,
+
+	TurnsToFlamout:   3,
+	TurnsToReplenish: 12,
+	TurnsToExplode:   10,
+
+	DefaultMaxBomb:    3,
+	DefaultBombRadius: 3,
+	DefaultSpeed:      1,
+}
+
+// NewState creates the state of a player starting at (x, y).
+func (r Rules) NewState(name, team string, x, y int) *player.State {
+	return &player.State{
+		Name:         name,
+		Team:         team,
+		X:            x,
+		Y:            y,
+		LastX:        -1,
+		LastY:        -1,
+		TurnDuration: r.TurnDuration,
+		Bombs:        0,
+		MaxBomb:      r.DefaultMaxBomb,
+		MaxRadius:    r.DefaultBombRadius,
+		Speed:        r.DefaultSpeed,
+		Alive:        true,
+		GameObject:   &objects.TboxPlayer{Name: name},
+	}
+}
+
+// Engine plays a match on a board.
+type Engine struct {
+	Game  *game.Game
+	Board board.Board
+	Feed  *board.Feed
+	Rules Rules
+
+	// Events of the match are published there as they happen.
+	Events *event.Bus
+
+	roundOver bool
+	log       *logger.Logger
+	// crashDir, when set, is where a crash dump is written if the engine
+	// panics, with the lines kept by recent.
+	crashDir string
+	recent   *logger.Ring
+}
+
+// New creates an engine for a game set up on a board.
+func New(g *game.Game, b board.Board, rules Rules, log *logger.Logger) *Engine {
+	e := &Engine{
+		Game:   g,
+		Board:  b,
+		Feed:   b.Feed(g.Turn()),
+		Rules:  rules,
+		Events: event.NewBus(),
+		log:    log,
+	}
+	e.Events.Subscribe(e.logEvent)
+	return e
+}
+
+// Step plays a turn: the actions scheduled for this turn happen, then the
+// moves sent by players are scheduled for the next turn.
+func (e *Engine) Step() {
+	defer e.dumpOnPanic()
+	if errs, ok := e.Game.RunSchedule(e.doAction).(scheduler.Errors); ok {
+		for _, err := range errs {
+			e.actionFailed(err)
+		}
+	}
+
+	e.applyPlayerMoves()
+
+	if winner, over := e.Over(); over && !e.roundOver {
+		e.roundOver = true
+		e.Events.Publish(event.RoundOver{Turn: e.now(), Winner: winner})
+	}
+}
+
+// doAction plays a turn of an action. Its panics are recovered, so a broken
+// action doesn't stop the others.
+func (e *Engine) doAction(a scheduler.Action, turn int) (err error) {
+	act := a.(*BomberAction)
+	e.log.With("turn", e.now(), "action", act.name).Debugf("Action %d/%d.", turn+1, act.Duration())
+	defer func() {
+		if r := recover(); r != nil {
+			e.crashed(r, debug.Stack())
+			err = fmt.Errorf("panic: %v", r)
+		}
+		if err != nil {
+			err = &ActionError{Action: act.name, Err: err}
+		}
+	}()
+	return act.doTurn(turn)
+}
+
+// actionFailed reports the failure of an action.
+func (e *Engine) actionFailed(err error) {
+	ev := event.ActionFailed{Turn: e.now(), Error: err.Error()}
+	if actErr, ok := err.(*ActionError); ok {
+		ev.Action, ev.Error = actErr.Action, actErr.Err.Error()
+	}
+	e.log.With("turn", ev.Turn, "action", ev.Action).Errorf("Action failed: %s.", ev.Error)
+	e.Events.Publish(ev)
+}
+
+// UpdatePlayers sends every player their state and what they see of the
+// board. Players not ready to receive it miss the update.
+func (e *Engine) UpdatePlayers() {
+	defer e.dumpOnPanic()
+	e.Feed.Advance(e.Game.Turn())
+	for pState, player := range e.Game.Players {
+		e.Feed.Update(e.Game, pState)
+		pState.Turn = e.Game.Turn()
+		select {
+		case player.Update() <- *pState:
+		default:
+			e.Feed.Dropped(pState)
+		}
+	}
+}
+
+// View is the board as a player sees it, with their allies. Dead players see
+// the whole board.
+func (e *Engine) View(pState *player.State) [][]*cell.Exported {
+	return e.Board.CloneFor(e.now(), e.Game.Vision, e.Game.Allies(pState))
+}
+
+// SetTurnDuration changes how long turns last, as players are told. The
+// clock playing the turns is the caller's.
+func (e *Engine) SetTurnDuration(d time.Duration) {
+	e.Rules.TurnDuration = d
+	for pState := range e.Game.Players {
+		pState.TurnDuration = d
+	}
+}
+
+// Over tells if the match is over, and which side won. There's no winner
+// when everyone died.
+func (e *Engine) Over() (winner string, over bool) {
+	sides := e.Game.AliveSides()
+	switch len(sides) {
+	case 0:
+		return "", true
+	case 1:
+		return sides[0], true
+	}
+	return "", false
+}
+
+// logFor logs about a player, on the turn being played.
+func (e *Engine) logFor(pState *player.State) *logger.Logger {
+	return e.log.With("turn", e.now(), "player", pState.Name)
+}
+
+// logEvent logs the events of the match, with their details as fields.
+func (e *Engine) logEvent(ev event.Event) {
+	if !e.log.Enabled(logger.Debug) {
+		return
+	}
+	e.log.With(event.Fields(ev)...).Debugf("Event %s.", ev.Kind())
+}
+
+// now is the turn being played.
+func (e *Engine) now() int {
+	return e.Game.Schedule.Now()
+}
+
+//////////////
+// Schedule
+
+type BomberAction struct {
+	name     string
+	duration int
+	doTurn   func(turn int) error
+}
+
+func (a *BomberAction) Duration() int {
+	return a.duration
+}
+
+// ActionError is the failure of a scheduled action.
+type ActionError struct {
+	Action string
+	Err    error
+}
+
+func (err *ActionError) Error() string {
+	return err.Action + ": " + err.Err.Error()
+}
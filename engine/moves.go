@@ -0,0 +1,193 @@
+package engine
+
+import (
+	"fmt"
+	"github.com/aybabtme/bomberman/event"
+	"github.com/aybabtme/bomberman/game"
+	"github.com/aybabtme/bomberman/player"
+	"github.com/aybabtme/bomberman/powerup"
+	"runtime/debug"
+)
+
+func (e *Engine) applyPlayerMoves() {
+	for pState, p := range e.Game.Players {
+		if pState.Alive {
+			e.applyMoves(pState, p)
+		}
+	}
+}
+
+// applyMoves schedules the moves sent by a player. A move breaking the engine
+// forfeits the player, instead of stopping the game.
+func (e *Engine) applyMoves(pState *player.State, p player.Player) {
+	defer func() {
+		if r := recover(); r != nil {
+			e.crashed(r, debug.Stack())
+			err := fmt.Errorf("panic: %v", r)
+			e.actionFailed(&ActionError{Action: pState.Name + ".moving", Err: err})
+			e.Forfeit(pState, err)
+		}
+	}()
+	if c, ok := p.(player.Crasher); ok {
+		select {
+		case err := <-c.Crashed():
+			e.Forfeit(pState, err)
+			return
+		default:
+		}
+	}
+	if pState.CursedTurns > 0 {
+		pState.CursedTurns--
+	}
+	for n := 0; n < pState.Speed; n++ {
+		select {
+		case m := <-p.Move():
+			e.Move(pState, m)
+		default:
+			return
+		}
+	}
+}
+
+// Move schedules a move of a player for the next turn. Moves are usually
+// read from the players, but can be given directly when driving a match
+// from outside.
+func (e *Engine) Move(pState *player.State, action player.Move) {
+	board := e.Board
+	dx, dy := 0, 0
+	switch action {
+	case player.Up:
+		dy--
+	case player.Down:
+		dy++
+	case player.Left:
+		dx--
+	case player.Right:
+		dx++
+	case player.PutBomb:
+		e.placeBomb(pState)
+		return
+	case player.Detonate:
+		e.detonate(pState)
+		return
+	default:
+		return
+	}
+
+	if pState.CursedTurns > 0 {
+		// The skull reverses the controls.
+		dx, dy = -dx, -dy
+	}
+
+	doMove := func(turn int) error {
+		// Fast players move many times per turn, so where they go is only
+		// known once the previous moves are done.
+		nextX, nextY := pState.X+dx, pState.Y+dy
+
+		if bomb, ok := board[nextX][nextY].Top().(*game.Bomb); ok && pState.CanKick {
+			e.kickBomb(bomb, dx, dy)
+			return nil
+		}
+
+		if !board.Traversable(nextX, nextY) {
+			return nil
+		}
+
+		if flame, ok := board[nextX][nextY].Top().(*game.Flame); ok && !e.shielded(pState) {
+			pState.Alive = false
+			e.logFor(pState).Infof("Died moving into flame.")
+			e.died(pState, flame.Owner)
+			cell := board[pState.X][pState.Y]
+			if !cell.Remove(pState.GameObject) {
+				e.logFor(pState).Panicf("Player not found at (%d, %d), cell=%#v",
+					pState.X, pState.Y, cell)
+			}
+			return nil
+		}
+
+		e.relocate(pState, nextX, nextY)
+		if x, y, ok := board.Teleport(nextX, nextY); ok {
+			e.logFor(pState).Debugf("Teleported to (%d, %d).", x, y)
+			e.relocate(pState, x, y)
+		}
+		return nil
+	}
+
+	e.Game.Schedule.Register(&BomberAction{
+		name:     fmt.Sprintf("%s.moving(%#v)", pState.Name, action),
+		duration: 1,
+		doTurn:   doMove,
+	}, 1)
+
+}
+
+// relocate moves a player to (x, y), picking the power-ups lying there.
+func (e *Engine) relocate(pState *player.State, x, y int) {
+	pState.LastX, pState.LastY = pState.X, pState.Y
+	pState.X, pState.Y = x, y
+
+	e.pickPowerUps(pState, x, y)
+
+	cell := e.Board[pState.LastX][pState.LastY]
+	if !cell.Remove(pState.GameObject) {
+		e.logFor(pState).Panicf("Player not found at (%d, %d), cell=%#v",
+			pState.X, pState.Y, cell)
+	}
+	e.Board[x][y].Push(pState.GameObject)
+	e.Events.Publish(event.PlayerMoved{
+		Turn:   e.now(),
+		Player: pState.Name,
+		FromX:  pState.LastX,
+		FromY:  pState.LastY,
+		X:      x,
+		Y:      y,
+	})
+}
+
+func (e *Engine) pickPowerUps(pState *player.State, x, y int) {
+	c := e.Board[x][y]
+	pu, ok := powerup.Find(c.Top())
+	if !ok {
+		return
+	}
+	pu.Apply(pState)
+	c.Pop()
+	e.logFor(pState).Infof("Powerup! %s", pu.Kind)
+	e.Events.Publish(event.PowerUpPicked{
+		Turn:    e.now(),
+		Player:  pState.Name,
+		PowerUp: string(pu.Kind),
+		X:       x,
+		Y:       y,
+	})
+}
+
+// Forfeit takes a player out of the match, like when its implementation
+// crashed.
+func (e *Engine) Forfeit(pState *player.State, reason error) {
+	if !pState.Alive {
+		return
+	}
+	pState.Alive = false
+	e.Board[pState.X][pState.Y].Remove(pState.GameObject)
+	e.logFor(pState).Errorf("Forfeited: %v.", reason)
+	e.Events.Publish(event.PlayerForfeited{
+		Turn:   e.now(),
+		Player: pState.Name,
+		X:      pState.X,
+		Y:      pState.Y,
+		Reason: reason.Error(),
+	})
+}
+
+// died publishes the death of a player.
+func (e *Engine) died(victim, killer *player.State) {
+	e.Events.Publish(event.PlayerDied{
+		Turn:     e.now(),
+		Player:   victim.Name,
+		Killer:   killer.Name,
+		X:        victim.X,
+		Y:        victim.Y,
+		TeamKill: killer != victim && killer.Team != "" && killer.Team == victim.Team,
+	})
+}
@@ -0,0 +1,70 @@
+package event
+
+import (
+	"sync"
+)
+
+// Handler is called with every event published on a bus.
+type Handler func(Event)
+
+// Bus hands the events of a match to its subscribers, in the order they
+// subscribed. Handlers run on the goroutine publishing, while the match waits
+// for them: slow subscribers should use Chan.
+type Bus struct {
+	mu     sync.Mutex
+	nextID int
+	subs   []subscriber
+}
+
+type subscriber struct {
+	id int
+	h  Handler
+}
+
+// NewBus creates a bus without subscribers.
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe calls h with every event published from now on, until the
+// returned function is called.
+func (b *Bus) Subscribe(h Handler) (unsubscribe func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	id := b.nextID
+	b.nextID++
+	b.subs = append(b.subs, subscriber{id, h})
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		for i, s := range b.subs {
+			if s.id == id {
+				b.subs = append(b.subs[:i:i], b.subs[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Chan subscribes a channel holding up to size events. Events published while
+// it's full are dropped, so a slow reader never holds the match back.
+func (b *Bus) Chan(size int) (<-chan Event, func()) {
+	events := make(chan Event, size)
+	unsubscribe := b.Subscribe(func(e Event) {
+		select {
+		case events <- e:
+		default:
+		}
+	})
+	return events, unsubscribe
+}
+
+// Publish hands an event to every subscriber.
+func (b *Bus) Publish(e Event) {
+	b.mu.Lock()
+	subs := b.subs
+	b.mu.Unlock()
+	for _, s := range subs {
+		s.h(e)
+	}
+}
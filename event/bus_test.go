@@ -0,0 +1,26 @@
+package event_test
+
+import (
+	"github.com/aybabtme/bomberman/event"
+	"testing"
+)
+
+func TestBusDeliversUntilUnsubscribed(t *testing.T) {
+	bus := event.NewBus()
+	var got []string
+	unsubscribe := bus.Subscribe(func(e event.Event) { got = append(got, e.Kind()) })
+	events, cancel := bus.Chan(1)
+	defer cancel()
+
+	bus.Publish(event.PlayerMoved{Player: "p1"})
+	unsubscribe()
+	bus.Publish(event.RoundOver{Winner: "p1"})
+
+	if len(got) != 1 || got[0] != "player_moved" {
+		t.Errorf("want only the first event, got %v", got)
+	}
+	// The channel was full for the second event.
+	if e := <-events; e.Kind() != "player_moved" || len(events) != 0 {
+		t.Errorf("want the first event then nothing, got %v and %d more", e, len(events))
+	}
+}
@@ -0,0 +1,145 @@
+// Package event describes what happens during a match, for whoever wants to
+// follow it: renderers, stats, replays, spectators.
+package event
+
+import (
+	"reflect"
+	"unicode"
+)
+
+// Event is something that happened during a match. Events name players
+// rather than point to them, so they can be sent elsewhere as they are.
+type Event interface {
+	// Kind names the type of event, like "player_moved".
+	Kind() string
+}
+
+// PlayerMoved is sent when a player walked to a neighbouring cell.
+type PlayerMoved struct {
+	Turn         int
+	Player       string
+	FromX, FromY int
+	X, Y         int
+}
+
+// BombPlaced is sent when a bomb lands on the board.
+type BombPlaced struct {
+	Turn       int
+	Owner      string
+	X, Y       int
+	Radius     int
+	ExplodesAt int
+}
+
+// BombExploded is sent when a bomb blows up, where it lay at the time.
+type BombExploded struct {
+	Turn   int
+	Owner  string
+	X, Y   int
+	Radius int
+}
+
+// FlameCleared is sent when the flames of an explosion die out.
+type FlameCleared struct {
+	Turn   int
+	Owner  string
+	X, Y   int
+	Radius int
+}
+
+// RockDestroyed is sent when flames burn a rock away.
+type RockDestroyed struct {
+	Turn  int
+	Owner string
+	X, Y  int
+}
+
+// PowerUpSpawned is sent when a power-up shows up from under a burnt rock.
+type PowerUpSpawned struct {
+	Turn    int
+	PowerUp string
+	X, Y    int
+}
+
+// PowerUpPicked is sent when a player walks on a power-up.
+type PowerUpPicked struct {
+	Turn    int
+	Player  string
+	PowerUp string
+	X, Y    int
+}
+
+// PlayerDied is sent when a player dies, with the owner of the bomb or flame
+// that killed them.
+type PlayerDied struct {
+	Turn   int
+	Player string
+	Killer string
+	X, Y   int
+	// TeamKill is set when the killer is a team-mate of the dead player.
+	TeamKill bool
+}
+
+// PlayerForfeited is sent when a player is taken out of the match, like when
+// its implementation crashed.
+type PlayerForfeited struct {
+	Turn   int
+	Player string
+	X, Y   int
+	Reason string
+}
+
+// ActionFailed is sent when a scheduled action fails or panics. The other
+// actions of the turn still happen.
+type ActionFailed struct {
+	Turn   int
+	Action string
+	Error  string
+}
+
+// RoundOver is sent when a single side is left standing, or none.
+type RoundOver struct {
+	Turn   int
+	Winner string
+}
+
+func (PlayerMoved) Kind() string     { return "player_moved" }
+func (BombPlaced) Kind() string      { return "bomb_placed" }
+func (BombExploded) Kind() string    { return "bomb_exploded" }
+func (FlameCleared) Kind() string    { return "flame_cleared" }
+func (RockDestroyed) Kind() string   { return "rock_destroyed" }
+func (PowerUpSpawned) Kind() string  { return "powerup_spawned" }
+func (PowerUpPicked) Kind() string   { return "powerup_picked" }
+func (PlayerDied) Kind() string      { return "player_died" }
+func (PlayerForfeited) Kind() string { return "player_forfeited" }
+func (ActionFailed) Kind() string    { return "action_failed" }
+func (RoundOver) Kind() string       { return "round_over" }
+
+// Fields lists the kind and the details of an event as keys and values, for
+// structured logs: "event", "player_moved", "turn", 3, "player", "p1"...
+func Fields(e Event) []interface{} {
+	fields := []interface{}{"event", e.Kind()}
+	v := reflect.ValueOf(e)
+	if v.Kind() != reflect.Struct {
+		return fields
+	}
+	for i := 0; i < v.NumField(); i++ {
+		fields = append(fields, snake(v.Type().Field(i).Name), v.Field(i).Interface())
+	}
+	return fields
+}
+
+// snake turns FromX into from_x.
+func snake(name string) string {
+	var out []rune
+	for i, r := range name {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				out = append(out, '_')
+			}
+			r = unicode.ToLower(r)
+		}
+		out = append(out, r)
+	}
+	return string(out)
+}
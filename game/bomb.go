@@ -0,0 +1,92 @@
+package game
+
+import (
+	"github.com/aybabtme/bomberman/cell"
+	"github.com/aybabtme/bomberman/objects"
+	"github.com/aybabtme/bomberman/player"
+)
+
+// Bomb is a bomb ticking on the board. It looks like objects.Bomb, but
+// remembers who placed it and where it currently is, since it can be kicked.
+type Bomb struct {
+	cell.GameObject
+	Owner    *player.State
+	X, Y     int
+	Radius   int
+	Pierce   bool
+	Exploded bool
+	// ExplodesAt is the turn at which the bomb explodes, unless it's
+	// detonated before.
+	ExplodesAt int
+}
+
+func (b *Bomb) Describe(l *cell.Layer, now int) {
+	l.Owner = b.Owner.Name
+	l.Radius = b.Radius
+	l.TurnsLeft = b.ExplodesAt - now
+}
+
+// Flame is left by an explosion until it clears. All the cells of a blast
+// share the same flame.
+type Flame struct {
+	cell.GameObject
+	Owner    *player.State
+	ClearsAt int
+}
+
+func (f *Flame) Describe(l *cell.Layer, now int) {
+	l.Owner = f.Owner.Name
+	l.TurnsLeft = f.ClearsAt - now
+}
+
+// PlaceBomb creates a bomb for owner at (x, y), exploding in turns, and
+// tracks it until it explodes.
+func (g *Game) PlaceBomb(owner *player.State, x, y, radius, turns int) *Bomb {
+	b := &Bomb{
+		GameObject: objects.Bomb,
+		Owner:      owner,
+		X:          x,
+		Y:          y,
+		Radius:     radius,
+		Pierce:     owner.Pierce,
+		ExplodesAt: g.Schedule.Now() + turns,
+	}
+	g.Bombs = append(g.Bombs, b)
+	return b
+}
+
+// NewFlame creates the flame of a bomb exploding now, clearing in turns.
+func (g *Game) NewFlame(b *Bomb, turns int) *Flame {
+	return &Flame{
+		GameObject: objects.Flame,
+		Owner:      b.Owner,
+		ClearsAt:   g.Schedule.Now() + turns,
+	}
+}
+
+// IsFlame tells if the object is the flame of an explosion.
+func IsFlame(o cell.GameObject) bool {
+	_, ok := o.(*Flame)
+	return ok
+}
+
+// RemoveBomb stops tracking a bomb.
+func (g *Game) RemoveBomb(b *Bomb) {
+	for i, other := range g.Bombs {
+		if other == b {
+			g.Bombs = append(g.Bombs[:i], g.Bombs[i+1:]...)
+			return
+		}
+	}
+}
+
+// BombsOf lists the bombs of owner still on the board.
+func (g *Game) BombsOf(owner *player.State) []*Bomb {
+	var owned []*Bomb
+	for _, b := range g.Bombs {
+		if b.Owner == owner {
+			owned = append(owned, b)
+		}
+	}
+	return owned
+}
@@ -1,56 +1,74 @@
 package game
 
 import (
-	"github.com/aybabtme/bomberman/cell"
-	"github.com/aybabtme/bomberman/objects"
 	"github.com/aybabtme/bomberman/player"
+	"github.com/aybabtme/bomberman/powerup"
 	"github.com/aybabtme/bomberman/scheduler"
-	"math/rand"
 	"time"
 )
 
 type Game struct {
-	rnd *rand.Rand
-
 	Schedule *scheduler.Scheduler
 	TurnTick *time.Ticker
 	turn     int
 	done     bool
+	// turnDuration is the period of TurnTick, stopped while paused.
+	turnDuration time.Duration
+	paused       bool
 
 	Players map[*player.State]player.Player
 
-	bombPULeft, radiusPULeft int
+	// FriendlyFire lets blasts hurt team-mates of the bomber.
+	FriendlyFire bool
+	// Vision limits what players see of the board.
+	Vision Vision
+
+	// Bombs ticking on the board.
+	Bombs []*Bomb
+
+	// PowerUps says which power-ups hide under rocks.
+	PowerUps powerup.Distribution
 }
 
-func NewGame(turnDuration time.Duration, totalBombs, totalRadius int) *Game {
+// NewGame creates a game hiding power-ups as the distribution says.
+func NewGame(turnDuration time.Duration, powerUps powerup.Distribution) *Game {
 	return &Game{
-		rnd:          rand.New(rand.NewSource(time.Now().UnixNano())),
 		Schedule:     scheduler.NewScheduler(),
 		TurnTick:     time.NewTicker(turnDuration),
+		turnDuration: turnDuration,
 		done:         false,
-		bombPULeft:   totalBombs,
-		radiusPULeft: totalRadius,
+		PowerUps:     powerUps,
 	}
 }
 
-func (g *Game) TryPutRadiusPU(c *cell.Cell, rocksToUse int) {
-	prob := float32(g.radiusPULeft) / float32(rocksToUse)
-	roll := g.rnd.Float32()
-	if roll < prob {
-		g.radiusPULeft--
-		c.Push(objects.RadiusPU)
-	}
+// Pause stops TurnTick, so no turn is played until Resume.
+func (g *Game) Pause() {
+	g.TurnTick.Stop()
+	g.paused = true
+}
+
+// Resume starts TurnTick again.
+func (g *Game) Resume() {
+	g.TurnTick.Reset(g.turnDuration)
+	g.paused = false
+}
+
+func (g *Game) Paused() bool {
+	return g.paused
 }
 
-func (g *Game) TryPutBombPU(c *cell.Cell, rocksToUse int) {
-	prob := float32(g.bombPULeft) / float32(rocksToUse)
-	roll := g.rnd.Float32()
-	if roll < prob {
-		g.bombPULeft--
-		c.Push(objects.BombPU)
+// SetTurnDuration changes the period of TurnTick.
+func (g *Game) SetTurnDuration(d time.Duration) {
+	g.turnDuration = d
+	if !g.paused {
+		g.TurnTick.Reset(d)
 	}
 }
 
+func (g *Game) TurnDuration() time.Duration {
+	return g.turnDuration
+}
+
 func (g *Game) SetDone() {
 	g.done = true
 }
@@ -59,12 +77,37 @@ func (g *Game) IsDone() bool {
 	return g.done
 }
 
-func (g *Game) RunSchedule(onTurn func(scheduler.Action, int) error) {
-	if g.Schedule.HasNext() {
-		g.Schedule.NextTurn()
-		g.Schedule.DoTurn(onTurn)
-		g.turn++
+// AliveSides lists the teams that still have a living player.
+func (g *Game) AliveSides() []string {
+	seen := make(map[string]bool)
+	sides := []string{}
+	for pState := range g.Players {
+		if pState.Alive && !seen[pState.Side()] {
+			seen[pState.Side()] = true
+			sides = append(sides, pState.Side())
+		}
+	}
+	return sides
+}
+
+// Hurts tells if a blast from bomber can hurt victim.
+func (g *Game) Hurts(bomber, victim *player.State) bool {
+	if g.FriendlyFire || bomber == victim || bomber.Team == "" {
+		return true
+	}
+	return bomber.Team != victim.Team
+}
+
+// RunSchedule plays the actions of the next turn, if any, and returns the
+// errors of those that failed.
+func (g *Game) RunSchedule(onTurn func(scheduler.Action, int) error) error {
+	if !g.Schedule.HasNext() {
+		return nil
 	}
+	g.Schedule.NextTurn()
+	err := g.Schedule.DoTurn(onTurn)
+	g.turn++
+	return err
 }
 
 func (g *Game) Turn() int {
@@ -0,0 +1,110 @@
+package game_test
+
+import (
+	"github.com/aybabtme/bomberman/game"
+	"github.com/aybabtme/bomberman/player"
+	"github.com/aybabtme/bomberman/powerup"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newGame(friendlyFire bool, players ...*player.State) *game.Game {
+	g := game.NewGame(time.Second, powerup.Distribution{})
+	g.TurnTick.Stop()
+	g.FriendlyFire = friendlyFire
+	g.Players = make(map[*player.State]player.Player, len(players))
+	for _, pState := range players {
+		g.Players[pState] = nil
+	}
+	return g
+}
+
+func TestHurts(t *testing.T) {
+	red1 := &player.State{Name: "red1", Team: "red", Alive: true}
+	red2 := &player.State{Name: "red2", Team: "red", Alive: true}
+	blue := &player.State{Name: "blue", Team: "blue", Alive: true}
+	solo := &player.State{Name: "solo", Alive: true}
+	tests := []struct {
+		name           string
+		friendlyFire   bool
+		bomber, victim *player.State
+		want           bool
+	}{
+		{"own bomb", false, red1, red1, true},
+		{"teammate", false, red1, red2, false},
+		{"teammate with friendly fire", true, red1, red2, true},
+		{"other team", false, red1, blue, true},
+		{"solo bomber", false, solo, red1, true},
+		{"solo victim", false, red1, solo, true},
+	}
+	for _, tt := range tests {
+		g := newGame(tt.friendlyFire, red1, red2, blue, solo)
+		if got := g.Hurts(tt.bomber, tt.victim); got != tt.want {
+			t.Errorf("%s: want %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestAliveSides(t *testing.T) {
+	tests := []struct {
+		name    string
+		players []*player.State
+		want    []string
+	}{
+		{"everyone alive", []*player.State{
+			{Name: "red1", Team: "red", Alive: true},
+			{Name: "red2", Team: "red", Alive: true},
+			{Name: "solo", Alive: true},
+		}, []string{"red", "solo"}},
+		{"team kept by one player", []*player.State{
+			{Name: "red1", Team: "red", Alive: false},
+			{Name: "red2", Team: "red", Alive: true},
+			{Name: "blue", Team: "blue", Alive: true},
+		}, []string{"blue", "red"}},
+		{"last team standing", []*player.State{
+			{Name: "red1", Team: "red", Alive: true},
+			{Name: "red2", Team: "red", Alive: true},
+			{Name: "blue", Team: "blue", Alive: false},
+			{Name: "solo", Alive: false},
+		}, []string{"red"}},
+		{"nobody left", []*player.State{
+			{Name: "red1", Team: "red", Alive: false},
+			{Name: "solo", Alive: false},
+		}, []string{}},
+	}
+	for _, tt := range tests {
+		got := newGame(false, tt.players...).AliveSides()
+		sort.Strings(got)
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("%s: want %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestAllies(t *testing.T) {
+	red1 := &player.State{Name: "red1", Team: "red", Alive: true}
+	red2 := &player.State{Name: "red2", Team: "red", Alive: true}
+	red3 := &player.State{Name: "red3", Team: "red", Alive: false}
+	solo := &player.State{Name: "solo", Alive: true}
+	g := newGame(false, red1, red2, red3, solo)
+	tests := []struct {
+		pState *player.State
+		want   []string
+	}{
+		{red1, []string{"red1", "red2"}},
+		{red3, []string{"red1", "red2"}},
+		{solo, []string{"solo"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, ally := range g.Allies(tt.pState) {
+			got = append(got, ally.Name)
+		}
+		sort.Strings(got)
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("%s: want allies %v, got %v", tt.pState.Name, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package game
+
+import (
+	"github.com/aybabtme/bomberman/player"
+)
+
+// Vision limits what players see of the board.
+type Vision struct {
+	// Radius is how far players see, in cells. Zero sees the whole board.
+	Radius int
+	// LineOfSight hides what's behind walls and rocks.
+	LineOfSight bool
+}
+
+// Limited tells if players can't see the whole board.
+func (v Vision) Limited() bool {
+	return v.Radius > 0 || v.LineOfSight
+}
+
+// Allies lists the living players fighting on the same side as pState,
+// including pState if alive. Allies share what they see.
+func (g *Game) Allies(pState *player.State) []*player.State {
+	var allies []*player.State
+	for other := range g.Players {
+		if other.Alive && other.Side() == pState.Side() {
+			allies = append(allies, other)
+		}
+	}
+	return allies
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"github.com/aybabtme/bomberman/gym"
+	"net"
+	"os"
+)
+
+// runGym serves a reinforcement-learning environment to trainers, over stdio
+// or a unix socket.
+func runGym(args []string) {
+	flags := flag.NewFlagSet("gym", flag.ExitOnError)
+	logOpts := logFlags(flags, "info")
+	configFile := flags.String("config", "", "JSON file overriding the default environment config")
+	socket := flags.String("socket", "", "unix socket to serve on, instead of stdio")
+	flags.Parse(args)
+	logOpts.open()
+
+	cfg := gym.DefaultConfig()
+	if *configFile != "" {
+		fd, err := os.Open(*configFile)
+		if err != nil {
+			log.Fatalf("Opening gym config: %v", err)
+		}
+		err = json.NewDecoder(fd).Decode(&cfg)
+		fd.Close()
+		if err != nil {
+			log.Fatalf("Reading gym config: %v", err)
+		}
+	}
+
+	logOpts.crashDumps(&cfg.Config)
+	// A bad config is reported once, before any trainer connects.
+	env, err := gym.New(cfg, log)
+	if err != nil {
+		log.Fatalf("Creating gym environment: %v", err)
+	}
+
+	if *socket == "" {
+		if err := gym.Serve(env, os.Stdin, os.Stdout); err != nil {
+			log.Fatalf("Serving gym on stdio: %v", err)
+		}
+		return
+	}
+
+	l, err := net.Listen("unix", *socket)
+	if err != nil {
+		log.Fatalf("Listening on %s: %v", *socket, err)
+	}
+	defer l.Close()
+	log.Infof("Serving gym on %s", *socket)
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			log.Fatalf("Accepting trainer: %v", err)
+		}
+		// Every trainer gets its own environment.
+		go func(conn net.Conn) {
+			defer conn.Close()
+			env, err := gym.New(cfg, log)
+			if err != nil {
+				log.Errorf("Creating gym environment: %v", err)
+				return
+			}
+			if err := gym.Serve(env, conn, conn); err != nil {
+				log.Errorf("Serving trainer: %v", err)
+			}
+		}(conn)
+	}
+}
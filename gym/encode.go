@@ -0,0 +1,100 @@
+package gym
+
+import (
+	"github.com/aybabtme/bomberman/cell"
+	"github.com/aybabtme/bomberman/player"
+	"github.com/aybabtme/bomberman/powerup"
+)
+
+// Channels names the planes of an observation tensor, in order. Object planes
+// hold 1 where the object is seen; timer planes hold the fraction of the
+// bomb or flame timer left.
+var Channels = channels()
+
+const (
+	chWall = iota
+	chRock
+	chBomb
+	chFlame
+	chBombTimer
+	chFlameTimer
+	chSelf
+	chAllies
+	chEnemies
+	chUnknown
+	chTeleporter
+	chPowerUps // one per power-up kind, in catalogue order
+)
+
+func channels() []string {
+	names := []string{
+		"wall", "rock", "bomb", "flame", "bomb_timer", "flame_timer",
+		"self", "allies", "enemies", "unknown", "teleporter",
+	}
+	for _, pu := range powerup.Catalogue {
+		names = append(names, "powerup_"+string(pu.Kind))
+	}
+	return names
+}
+
+// Encode turns the view of a player into a tensor of shape
+// [len(Channels)][width][height], flattened in that order. Timers are scaled
+// by the turns bombs take to explode and flames to clear.
+func Encode(pState *player.State, view [][]*cell.Exported, bombTurns, flameTurns int) []float32 {
+	width, height := len(view), len(view[0])
+	t := make([]float32, len(Channels)*width*height)
+	set := func(ch, x, y int, v float32) {
+		t[(ch*width+x)*height+y] = v
+	}
+
+	mates := make(map[string]bool, len(pState.Teammates))
+	for _, mate := range pState.Teammates {
+		mates[mate] = true
+	}
+
+	for x := range view {
+		for y, e := range view[x] {
+			for _, l := range e.Layers {
+				switch l.Kind {
+				case cell.Wall:
+					set(chWall, x, y, 1)
+				case cell.Rock:
+					set(chRock, x, y, 1)
+				case cell.Bomb:
+					set(chBomb, x, y, 1)
+					set(chBombTimer, x, y, scale(l.TurnsLeft, bombTurns))
+				case cell.Flame:
+					set(chFlame, x, y, 1)
+					set(chFlameTimer, x, y, scale(l.TurnsLeft, flameTurns))
+				case cell.Player:
+					switch {
+					case l.Name == pState.Name:
+						set(chSelf, x, y, 1)
+					case mates[l.Name]:
+						set(chAllies, x, y, 1)
+					default:
+						set(chEnemies, x, y, 1)
+					}
+				case cell.PowerUp:
+					for i, pu := range powerup.Catalogue {
+						if string(pu.Kind) == l.PowerUp {
+							set(chPowerUps+i, x, y, 1)
+						}
+					}
+				case cell.Unknown:
+					set(chUnknown, x, y, 1)
+				case cell.Teleporter:
+					set(chTeleporter, x, y, 1)
+				}
+			}
+		}
+	}
+	return t
+}
+
+func scale(left, total int) float32 {
+	if total <= 0 {
+		return 0
+	}
+	return float32(left) / float32(total)
+}
@@ -0,0 +1,236 @@
+// Package gym wraps the engine in a reinforcement-learning environment: agents
+// act through Step instead of sending moves, and get back tensors of what they
+// see and rewards shaped from the events of the turn.
+package gym
+
+import (
+	"fmt"
+	"github.com/aybabtme/bomberman/config"
+	"github.com/aybabtme/bomberman/engine"
+	"github.com/aybabtme/bomberman/event"
+	"github.com/aybabtme/bomberman/logger"
+	"github.com/aybabtme/bomberman/match"
+	"github.com/aybabtme/bomberman/player"
+	"github.com/aybabtme/bomberman/powerup"
+)
+
+// Config describes the matches of an environment.
+type Config struct {
+	match.Config
+
+	// Agents is how many players are controlled through Step.
+	Agents int `json:"agents"`
+	// Opponents are the kinds of players against the agents: random,
+	// wandering, immobile, or any kind registered with the match package.
+	Opponents []string `json:"opponents"`
+	// Teams puts all the agents in one team, against the opponents.
+	Teams bool `json:"teams"`
+
+	Rewards Rewards `json:"rewards"`
+}
+
+// Rewards shape what agents get for the events of a turn.
+type Rewards struct {
+	Kill     float64 `json:"kill"`
+	TeamKill float64 `json:"team_kill"`
+	Death    float64 `json:"death"`
+	PowerUp  float64 `json:"powerup"`
+	Skull    float64 `json:"skull"`
+	// Survival is given every turn an agent is alive.
+	Survival float64 `json:"survival"`
+	Win      float64 `json:"win"`
+	Lose     float64 `json:"lose"`
+}
+
+// DefaultConfig is one agent against a random player on a small classic arena.
+func DefaultConfig() Config {
+	return Config{
+		Config: match.Config{
+			Arena:          "classic",
+			Width:          13,
+			Height:         11,
+			RockFreeRadius: 1,
+			RockDensity:    0.5,
+			PowerUps: powerup.Distribution{Counts: map[powerup.Kind]int{
+				powerup.Bomb:   6,
+				powerup.Radius: 6,
+				powerup.Kick:   1,
+				powerup.Speed:  1,
+				powerup.Shield: 1,
+				powerup.Skull:  1,
+			}},
+			Rules:    engine.DefaultRules,
+			MaxTurns: 1000,
+		},
+		Agents:    1,
+		Opponents: []string{"random"},
+		Rewards: Rewards{
+			Kill:     1,
+			TeamKill: -1,
+			Death:    -1,
+			PowerUp:  0.1,
+			Skull:    -0.1,
+			Survival: 0,
+			Win:      1,
+			Lose:     -1,
+		},
+	}
+}
+
+// Observation is what an agent knows after a turn.
+type Observation struct {
+	Name   string    `json:"name"`
+	Alive  bool      `json:"alive"`
+	X      int       `json:"x"`
+	Y      int       `json:"y"`
+	Tensor []float32 `json:"tensor"`
+}
+
+// Info tells how the match went, for debugging and metrics.
+type Info struct {
+	Turn   int    `json:"turn"`
+	Winner string `json:"winner,omitempty"`
+	// Truncated is set when the match ended because of MaxTurns.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// Env plays one match at a time.
+type Env struct {
+	cfg   Config
+	seats []config.Entry
+	log   *logger.Logger
+
+	match    *match.Match
+	agents   []*player.State
+	rewards  map[string]float64
+	finished bool
+}
+
+// New creates an environment. Call Reset to start a match.
+func New(cfg Config, log *logger.Logger) (*Env, error) {
+	if cfg.Agents < 1 {
+		return nil, fmt.Errorf("need at least one agent, got %d", cfg.Agents)
+	}
+	team := ""
+	if cfg.Teams {
+		team = "agents"
+	}
+	var seats []config.Entry
+	for i := 0; i < cfg.Agents; i++ {
+		seats = append(seats, config.Entry{Name: fmt.Sprintf("agent%d", i), Kind: match.External, Team: team})
+	}
+	for i, kind := range cfg.Opponents {
+		if !match.Playable(kind) || kind == match.External {
+			return nil, fmt.Errorf("opponent %d: unknown kind %q", i, kind)
+		}
+		seats = append(seats, config.Entry{Name: fmt.Sprintf("bot%d", i), Kind: kind})
+	}
+	return &Env{cfg: cfg, seats: seats, log: log}, nil
+}
+
+// Shape is the shape of observation tensors: channels, width and height.
+func (e *Env) Shape() [3]int {
+	return [3]int{len(Channels), e.cfg.Width, e.cfg.Height}
+}
+
+// Reset starts a new match. The same seed always gives the same arena, power-ups
+// and opponent moves.
+func (e *Env) Reset(seed int64) ([]Observation, error) {
+	m, err := match.New(e.cfg.Config, e.seats, seed, e.log)
+	if err != nil {
+		return nil, err
+	}
+	m.Engine.Events.Subscribe(e.reward)
+
+	if e.match != nil {
+		e.match.Close()
+	}
+	e.match = m
+	e.agents = m.Players[:e.cfg.Agents]
+	e.rewards = make(map[string]float64)
+	e.finished = false
+	return e.observe(), nil
+}
+
+// Step plays a turn with one action per agent, in the order of the
+// observations. Dead agents' actions are ignored. Once done, the match must be
+// Reset.
+func (e *Env) Step(actions []player.Move) ([]Observation, []float64, bool, Info, error) {
+	if e.match == nil || e.finished {
+		return nil, nil, true, Info{}, fmt.Errorf("match is over, reset the environment")
+	}
+	if len(actions) != len(e.agents) {
+		return nil, nil, false, Info{}, fmt.Errorf("need %d actions, got %d", len(e.agents), len(actions))
+	}
+
+	for name := range e.rewards {
+		delete(e.rewards, name)
+	}
+
+	for i, pState := range e.agents {
+		if pState.Alive {
+			e.match.Move(pState, actions[i])
+		}
+	}
+	e.match.Step()
+
+	info := Info{Turn: e.match.Engine.Game.Turn()}
+	res, over := e.match.Over()
+	rewards := make([]float64, len(e.agents))
+	for i, pState := range e.agents {
+		rewards[i] = e.rewards[pState.Name]
+		if pState.Alive {
+			rewards[i] += e.cfg.Rewards.Survival
+		}
+		if over && !res.Truncated {
+			if !res.Draw() && res.Winner == pState.Side() {
+				rewards[i] += e.cfg.Rewards.Win
+			} else {
+				rewards[i] += e.cfg.Rewards.Lose
+			}
+		}
+	}
+	if over {
+		info.Winner, info.Truncated = res.Winner, res.Truncated
+	}
+	e.finished = over
+	return e.observe(), rewards, over, info, nil
+}
+
+func (e *Env) observe() []Observation {
+	obs := make([]Observation, len(e.agents))
+	for i, pState := range e.agents {
+		obs[i] = Observation{
+			Name:  pState.Name,
+			Alive: pState.Alive,
+			X:     pState.X,
+			Y:     pState.Y,
+			Tensor: Encode(pState, pState.Board,
+				e.cfg.Rules.TurnsToExplode, e.cfg.Rules.TurnsToFlamout),
+		}
+	}
+	return obs
+}
+
+// reward shapes the rewards of the turn from the events of the match.
+func (e *Env) reward(ev event.Event) {
+	switch ev := ev.(type) {
+	case event.PlayerDied:
+		e.rewards[ev.Player] += e.cfg.Rewards.Death
+		switch {
+		case ev.Killer == ev.Player:
+		case ev.TeamKill:
+			e.rewards[ev.Killer] += e.cfg.Rewards.TeamKill
+		default:
+			e.rewards[ev.Killer] += e.cfg.Rewards.Kill
+		}
+	case event.PlayerForfeited:
+		e.rewards[ev.Player] += e.cfg.Rewards.Death
+	case event.PowerUpPicked:
+		if powerup.Kind(ev.PowerUp) == powerup.Skull {
+			e.rewards[ev.Player] += e.cfg.Rewards.Skull
+		} else {
+			e.rewards[ev.Player] += e.cfg.Rewards.PowerUp
+		}
+	}
+}
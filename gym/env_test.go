@@ -0,0 +1,92 @@
+package gym_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/aybabtme/bomberman/gym"
+	"github.com/aybabtme/bomberman/logger"
+	"github.com/aybabtme/bomberman/player"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func newEnv(t *testing.T) *gym.Env {
+	cfg := gym.DefaultConfig()
+	cfg.Opponents = []string{"immobile"}
+	env, err := gym.New(cfg, logger.NewWriter("", ioutil.Discard, logger.Error))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return env
+}
+
+func TestStepRewardsDeath(t *testing.T) {
+	env := newEnv(t)
+	obs, err := env.Reset(42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shape := env.Shape()
+	if want := shape[0] * shape[1] * shape[2]; len(obs) != 1 || len(obs[0].Tensor) != want {
+		t.Fatalf("want one observation of %d values, got %d", want, len(obs))
+	}
+
+	actions := []player.Move{player.PutBomb}
+	var total float64
+	for turn := 0; turn < 30; turn++ {
+		_, rewards, done, info, err := env.Step(actions)
+		if err != nil {
+			t.Fatal(err)
+		}
+		total += rewards[0]
+		actions[0] = ""
+		if done {
+			if info.Winner != "bot0" {
+				t.Errorf("want bot0 to win, got %q", info.Winner)
+			}
+			if total != -2 {
+				t.Errorf("want death and defeat to cost 2, got %v", total)
+			}
+			return
+		}
+	}
+	t.Fatal("agent survived its own bomb")
+}
+
+func TestServe(t *testing.T) {
+	env := newEnv(t)
+	in := strings.NewReader(`{"cmd":"spec"}
+{"cmd":"step","actions":["up"]}
+{"cmd":"reset","seed":1}
+{"cmd":"step","actions":["up"]}
+`)
+	out := bytes.NewBuffer(nil)
+	if err := gym.Serve(env, in, out); err != nil {
+		t.Fatal(err)
+	}
+
+	dec := json.NewDecoder(out)
+	var resps []gym.Response
+	for dec.More() {
+		var resp gym.Response
+		if err := dec.Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		resps = append(resps, resp)
+	}
+	if len(resps) != 4 {
+		t.Fatalf("want 4 responses, got %d", len(resps))
+	}
+	if len(resps[0].Channels) != resps[0].Shape[0] {
+		t.Errorf("spec: %d channels for shape %v", len(resps[0].Channels), resps[0].Shape)
+	}
+	if resps[1].Error == "" {
+		t.Errorf("want stepping before reset to fail")
+	}
+	for _, resp := range resps[2:] {
+		if resp.Error != "" || len(resp.Observations) != 1 {
+			t.Errorf("want one observation, got %+v", resp)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package gym
+
+import (
+	"bufio"
+	"encoding/json"
+	"github.com/aybabtme/bomberman/player"
+	"io"
+)
+
+// Actions lists what agents can do in a turn. An empty action stays put.
+var Actions = []player.Move{
+	player.Move(""),
+	player.Up,
+	player.Down,
+	player.Left,
+	player.Right,
+	player.PutBomb,
+	player.Detonate,
+}
+
+// Request is a line sent to the server. Cmd is one of:
+//
+//	spec: describe the observations and actions
+//	reset: start a match with Seed
+//	step: play a turn with one of Actions per agent
+type Request struct {
+	Cmd     string        `json:"cmd"`
+	Seed    int64         `json:"seed,omitempty"`
+	Actions []player.Move `json:"actions,omitempty"`
+}
+
+// Response is the line the server answers each request with.
+type Response struct {
+	Error string `json:"error,omitempty"`
+
+	// spec
+	Shape    []int         `json:"shape,omitempty"`
+	Channels []string      `json:"channels,omitempty"`
+	Actions  []player.Move `json:"actions,omitempty"`
+
+	// reset and step
+	Observations []Observation `json:"observations,omitempty"`
+	Rewards      []float64     `json:"rewards,omitempty"`
+	Done         bool          `json:"done,omitempty"`
+	Info         *Info         `json:"info,omitempty"`
+}
+
+// Serve answers requests read from r, one JSON object per line, until r is
+// closed. Trainers run it over stdio or a unix socket.
+func Serve(env *Env, r io.Reader, w io.Writer) error {
+	scan := bufio.NewScanner(r)
+	// Observations are big, but requests stay small; allow some slack for
+	// many agents.
+	scan.Buffer(make([]byte, 64*1024), 1024*1024)
+	enc := json.NewEncoder(w)
+
+	for scan.Scan() {
+		var req Request
+		var resp Response
+		if err := json.Unmarshal(scan.Bytes(), &req); err != nil {
+			resp.Error = err.Error()
+		} else {
+			resp = env.handle(req)
+		}
+		if err := enc.Encode(resp); err != nil {
+			return err
+		}
+	}
+	return scan.Err()
+}
+
+func (e *Env) handle(req Request) Response {
+	var resp Response
+	switch req.Cmd {
+	case "spec":
+		shape := e.Shape()
+		resp.Shape = shape[:]
+		resp.Channels = Channels
+		resp.Actions = Actions
+	case "reset":
+		obs, err := e.Reset(req.Seed)
+		if err != nil {
+			resp.Error = err.Error()
+			break
+		}
+		resp.Observations = obs
+		resp.Info = &Info{Turn: e.match.Engine.Game.Turn()}
+	case "step":
+		obs, rewards, done, info, err := e.Step(req.Actions)
+		if err != nil {
+			resp.Error = err.Error()
+			break
+		}
+		resp.Observations, resp.Rewards, resp.Done, resp.Info = obs, rewards, done, &info
+	default:
+		resp.Error = "unknown command " + req.Cmd
+	}
+	return resp
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"github.com/aybabtme/bomberman/engine"
+	"github.com/aybabtme/bomberman/keys"
+	"github.com/aybabtme/bomberman/player"
+	"github.com/nsf/termbox-go"
+	"os"
+	"sort"
+	"strings"
+)
+
+var (
+	// gameKeys control the game, as bound by -keys.
+	gameKeys map[keys.Key]keys.Action
+	// help lists the bindings, and shows over the game when asked.
+	help      []string
+	helpShown bool
+	// helpResume tells if the game played when help was shown.
+	helpResume bool
+)
+
+// loadKeys reads the bindings of keys, if a file is given, and binds the
+// controls of the game.
+func loadKeys(filename string) (*keys.Config, error) {
+	cfg := &keys.Config{}
+	if filename != "" {
+		fd, err := os.Open(filename)
+		if err != nil {
+			return nil, err
+		}
+		defer fd.Close()
+		if cfg, err = keys.Load(fd); err != nil {
+			return nil, err
+		}
+	}
+	game, err := cfg.GameKeys()
+	if err != nil {
+		return nil, err
+	}
+	gameKeys = game
+	return cfg, nil
+}
+
+// presetNames lists the presets, for flags.
+func presetNames() []string {
+	var names []string
+	for name := range keys.Presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// localMove is what a key does: move a local player.
+type localMove struct {
+	name  string
+	input chan<- player.Move
+	move  player.Move
+}
+
+// describeKeys lists the controls of the game, then the keys of every local
+// player.
+func describeKeys(controls map[keys.Key]localMove) []string {
+	lines := []string{"game    " + strings.Join(keys.DescribeGame(gameKeys), "  ")}
+	byPlayer := make(map[string]map[keys.Key]player.Move)
+	for k, lm := range controls {
+		if byPlayer[lm.name] == nil {
+			byPlayer[lm.name] = make(map[keys.Key]player.Move)
+		}
+		byPlayer[lm.name][k] = lm.move
+	}
+	var names []string
+	for name := range byPlayer {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		lines = append(lines, fmt.Sprintf("%-8s%s", name, strings.Join(keys.DescribeMoves(byPlayer[name]), "  ")))
+	}
+	return lines
+}
+
+// keyHint tells the key of a control, like "p: pause", or nothing if it has
+// no key.
+func keyHint(action keys.Action, what string) string {
+	var names []string
+	for k, a := range gameKeys {
+		if a == action {
+			names = append(names, k.String())
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	sort.Strings(names)
+	return names[0] + ": " + what
+}
+
+// hints joins the hints of controls that have keys.
+func hints(h ...string) string {
+	var bound []string
+	for _, hint := range h {
+		if hint != "" {
+			bound = append(bound, hint)
+		}
+	}
+	return strings.Join(bound, ", ")
+}
+
+// showHelp pauses the game and lists the bindings over it.
+func showHelp(eng *engine.Engine) {
+	g := eng.Game
+	helpShown, helpResume = true, !g.Paused()
+	g.Pause()
+	lines := append([]string{"Keys", ""}, help...)
+	lines = append(lines, "", "Press a key, other than moves, to go back to the game.")
+	width := 0
+	for _, line := range lines {
+		if n := len([]rune(line)); n > width {
+			width = n
+		}
+	}
+	for y, line := range lines {
+		drawText(1, 1+y, fmt.Sprintf(" %-*s ", width, line))
+	}
+	termbox.Flush()
+}
+
+// hideHelp goes back to the game, playing again if it played before.
+func hideHelp(eng *engine.Engine) {
+	helpShown = false
+	if helpResume {
+		eng.Game.Resume()
+	}
+	termbox.Clear(termbox.ColorDefault, termbox.ColorDefault)
+	drawBoard(eng)
+	drawStatus(eng)
+}
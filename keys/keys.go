@@ -0,0 +1,330 @@
+// Package keys binds the keys of the keyboard to the moves of local players
+// and to the controls of the game.
+package keys
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/aybabtme/bomberman/config"
+	"github.com/aybabtme/bomberman/player"
+	"github.com/nsf/termbox-go"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Key is a key of the keyboard as termbox reports it: a special key, or a
+// rune.
+type Key struct {
+	Key termbox.Key
+	Ch  rune
+}
+
+// Of is the key of a keyboard event.
+func Of(ev termbox.Event) Key {
+	return Key{Key: ev.Key, Ch: ev.Ch}
+}
+
+// special are the keys with a name, other than runes.
+var special = map[string]termbox.Key{
+	"up":        termbox.KeyArrowUp,
+	"down":      termbox.KeyArrowDown,
+	"left":      termbox.KeyArrowLeft,
+	"right":     termbox.KeyArrowRight,
+	"space":     termbox.KeySpace,
+	"enter":     termbox.KeyEnter,
+	"tab":       termbox.KeyTab,
+	"esc":       termbox.KeyEsc,
+	"backspace": termbox.KeyBackspace2,
+	"insert":    termbox.KeyInsert,
+	"delete":    termbox.KeyDelete,
+	"home":      termbox.KeyHome,
+	"end":       termbox.KeyEnd,
+	"pgup":      termbox.KeyPgup,
+	"pgdn":      termbox.KeyPgdn,
+	"f1":        termbox.KeyF1,
+	"f2":        termbox.KeyF2,
+	"f3":        termbox.KeyF3,
+	"f4":        termbox.KeyF4,
+	"ctrl-c":    termbox.KeyCtrlC,
+}
+
+// Parse reads the name of a key: a special key like "up", "space" or
+// "ctrl-c", or a single rune like "w".
+func Parse(name string) (Key, error) {
+	if k, ok := special[strings.ToLower(name)]; ok {
+		return Key{Key: k}, nil
+	}
+	if name == " " {
+		return Key{Key: termbox.KeySpace}, nil
+	}
+	if r := []rune(name); len(r) == 1 {
+		return Key{Ch: r[0]}, nil
+	}
+	return Key{}, fmt.Errorf("unknown key %q", name)
+}
+
+// String names the key like Parse reads it.
+func (k Key) String() string {
+	if k.Ch != 0 {
+		return string(k.Ch)
+	}
+	for name, sk := range special {
+		if sk == k.Key {
+			return name
+		}
+	}
+	return fmt.Sprintf("key %d", k.Key)
+}
+
+// Action is a control of the game.
+type Action string
+
+const (
+	Pause Action = "pause"
+	// Step plays a single turn while paused.
+	Step   Action = "step"
+	Faster Action = "faster"
+	Slower Action = "slower"
+	// Follow switches the player followed when spectating. Its keys may
+	// also move players, since spectators don't play.
+	Follow Action = "follow"
+	Help   Action = "help"
+	Quit   Action = "quit"
+)
+
+// Actions are all the controls of the game, in the order help lists them.
+var Actions = []Action{Pause, Step, Faster, Slower, Follow, Help, Quit}
+
+// DefaultGame binds the controls of the game, unless the config binds them.
+var DefaultGame = map[string]Action{
+	"p":      Pause,
+	"n":      Step,
+	"+":      Faster,
+	"=":      Faster,
+	"-":      Slower,
+	"tab":    Follow,
+	"?":      Help,
+	"ctrl-c": Quit,
+}
+
+// Moves are all the moves keys are bound to, in the order help lists them.
+var Moves = []player.Move{player.Up, player.Down, player.Left, player.Right, player.PutBomb, player.Detonate}
+
+// Presets are key maps ready to use, from the name of a key to the move it
+// makes.
+var Presets = map[string]map[string]string{
+	"arrows": {"up": "up", "down": "down", "left": "left", "right": "right", "space": "bomb", "enter": "detonate"},
+	"wasd":   {"w": "up", "s": "down", "a": "left", "d": "right", "tab": "bomb", "e": "detonate"},
+	"hjkl":   {"k": "up", "j": "down", "h": "left", "l": "right", "x": "bomb", "c": "detonate"},
+}
+
+// DefaultPresets are given to local players without keys of their own, in
+// roster order.
+var DefaultPresets = []string{"arrows", "wasd", "hjkl"}
+
+// ParseMoves reads a key map, from the names of keys to those of moves.
+func ParseMoves(keys map[string]string) (map[Key]player.Move, error) {
+	moves := make(map[Key]player.Move, len(keys))
+	for name, move := range keys {
+		k, err := Parse(name)
+		if err != nil {
+			return nil, err
+		}
+		if !known(player.Move(move)) {
+			return nil, fmt.Errorf("key %q: unknown move %q", name, move)
+		}
+		moves[k] = player.Move(move)
+	}
+	return moves, nil
+}
+
+func known(move player.Move) bool {
+	for _, m := range Moves {
+		if m == move {
+			return true
+		}
+	}
+	return false
+}
+
+// Binding gives keys to a local player: a preset, and keys added over it.
+type Binding struct {
+	Preset string            `json:"preset,omitempty"`
+	Keys   map[string]string `json:"keys,omitempty"`
+}
+
+// Moves reads the key map of the binding.
+func (b Binding) Moves() (map[Key]player.Move, error) {
+	keys := make(map[string]string)
+	if b.Preset != "" {
+		preset, ok := Presets[b.Preset]
+		if !ok {
+			return nil, fmt.Errorf("unknown preset %q", b.Preset)
+		}
+		for k, m := range preset {
+			keys[k] = m
+		}
+	}
+	for k, m := range b.Keys {
+		keys[k] = m
+	}
+	return ParseMoves(keys)
+}
+
+// Config binds keys, as read from a file.
+type Config struct {
+	// Game binds keys to the controls of the game. The default keys of
+	// the controls it binds are dropped.
+	Game map[string]Action `json:"game,omitempty"`
+	// Players binds keys to local players, by name.
+	Players map[string]Binding `json:"players,omitempty"`
+}
+
+// Load decodes a JSON config and validates it.
+func Load(r io.Reader) (*Config, error) {
+	cfg := &Config{}
+	if err := json.NewDecoder(r).Decode(cfg); err != nil {
+		return nil, fmt.Errorf("decoding keys, %v", err)
+	}
+	if _, err := cfg.GameKeys(); err != nil {
+		return nil, err
+	}
+	for name, b := range cfg.Players {
+		if _, err := b.Moves(); err != nil {
+			return nil, fmt.Errorf("player %q: %v", name, err)
+		}
+	}
+	return cfg, nil
+}
+
+// GameKeys binds the controls of the game.
+func (c *Config) GameKeys() (map[Key]Action, error) {
+	rebound := make(map[Action]bool)
+	for _, action := range c.Game {
+		rebound[action] = true
+	}
+	game := make(map[Key]Action)
+	bind := func(name string, action Action) error {
+		k, err := Parse(name)
+		if err != nil {
+			return err
+		}
+		game[k] = action
+		return nil
+	}
+	for name, action := range DefaultGame {
+		if !rebound[action] {
+			bind(name, action)
+		}
+	}
+	for name, action := range c.Game {
+		if !knownAction(action) {
+			return nil, fmt.Errorf("key %q: unknown control %q", name, action)
+		}
+		if err := bind(name, action); err != nil {
+			return nil, err
+		}
+	}
+	return game, nil
+}
+
+func knownAction(action Action) bool {
+	for _, a := range Actions {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
+
+// Control is what a key does: move a local player.
+type Control struct {
+	Player string
+	Move   player.Move
+}
+
+// Controls binds the keys of the local players of a roster, each from the
+// config, else from the roster, else from the next default preset. A key
+// can't move two players, nor move a player and control the game, but for
+// Follow: only spectators follow players.
+func (c *Config) Controls(roster []config.Entry, game map[Key]Action) (map[Key]Control, error) {
+	controls := make(map[Key]Control)
+	locals := 0
+	for _, e := range roster {
+		if e.Kind != "local" {
+			continue
+		}
+		moves, err := c.playerMoves(e, locals)
+		if err != nil {
+			return nil, fmt.Errorf("player %q: %v", e.Name, err)
+		}
+		locals++
+		for k, move := range moves {
+			if other, taken := controls[k]; taken {
+				return nil, fmt.Errorf("player %q: key %s already moves %s", e.Name, k, other.Player)
+			}
+			if action, ok := game[k]; ok && action != Follow {
+				return nil, fmt.Errorf("player %q: key %s controls the game (%s)", e.Name, k, action)
+			}
+			controls[k] = Control{Player: e.Name, Move: move}
+		}
+	}
+	return controls, nil
+}
+
+// playerMoves is the key map of the nth local player.
+func (c *Config) playerMoves(e config.Entry, nth int) (map[Key]player.Move, error) {
+	if b, ok := c.Players[e.Name]; ok {
+		return b.Moves()
+	}
+	if e.Keys != nil {
+		return ParseMoves(e.Keys)
+	}
+	if nth >= len(DefaultPresets) {
+		return nil, fmt.Errorf("only %d local players have default keys, give them keys", len(DefaultPresets))
+	}
+	return Binding{Preset: DefaultPresets[nth]}.Moves()
+}
+
+// DescribeGame lists the keys of every control of the game, like
+// "faster: +, =".
+func DescribeGame(game map[Key]Action) []string {
+	bound := make(map[Key]string, len(game))
+	for k, action := range game {
+		bound[k] = string(action)
+	}
+	order := make([]string, len(Actions))
+	for i, action := range Actions {
+		order[i] = string(action)
+	}
+	return describe(bound, order)
+}
+
+// DescribeMoves lists the keys of every move of a player, like "up: k".
+func DescribeMoves(moves map[Key]player.Move) []string {
+	bound := make(map[Key]string, len(moves))
+	for k, move := range moves {
+		bound[k] = string(move)
+	}
+	order := make([]string, len(Moves))
+	for i, move := range Moves {
+		order[i] = string(move)
+	}
+	return describe(bound, order)
+}
+
+func describe(bound map[Key]string, order []string) []string {
+	names := make(map[string][]string)
+	for k, what := range bound {
+		names[what] = append(names[what], k.String())
+	}
+	var lines []string
+	for _, what := range order {
+		if keys := names[what]; len(keys) > 0 {
+			sort.Strings(keys)
+			lines = append(lines, what+": "+strings.Join(keys, ", "))
+		}
+	}
+	return lines
+}
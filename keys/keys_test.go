@@ -0,0 +1,157 @@
+package keys_test
+
+import (
+	"github.com/aybabtme/bomberman/config"
+	"github.com/aybabtme/bomberman/keys"
+	"github.com/aybabtme/bomberman/player"
+	"github.com/nsf/termbox-go"
+	"strings"
+	"testing"
+)
+
+func TestPresetsLeaveGameKeysFree(t *testing.T) {
+	game, err := (&keys.Config{}).GameKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	taken := make(map[keys.Key]string)
+	for _, name := range keys.DefaultPresets {
+		moves, err := keys.Binding{Preset: name}.Moves()
+		if err != nil {
+			t.Fatalf("preset %q: %v", name, err)
+		}
+		if len(moves) != len(keys.Moves) {
+			t.Errorf("preset %q: want a key for each of the %d moves, got %v", name, len(keys.Moves), moves)
+		}
+		for k := range moves {
+			if action, ok := game[k]; ok && action != keys.Follow {
+				t.Errorf("preset %q: key %s controls the game (%s)", name, k, action)
+			}
+			if other, ok := taken[k]; ok {
+				t.Errorf("preset %q: key %s is also in preset %q", name, k, other)
+			}
+			taken[k] = name
+		}
+	}
+}
+
+func TestConfigRebindsControls(t *testing.T) {
+	cfg, err := keys.Load(strings.NewReader(`{
+		"game": {"q": "quit", "f1": "help"},
+		"players": {"p1": {"preset": "hjkl", "keys": {"space": "bomb"}}}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	game, _ := cfg.GameKeys()
+	if game[keys.Key{Ch: 'q'}] != keys.Quit || game[keys.Key{Key: termbox.KeyF1}] != keys.Help {
+		t.Errorf("want q to quit and f1 to help, got %v", game)
+	}
+	if _, ok := game[keys.Key{Key: termbox.KeyCtrlC}]; ok {
+		t.Errorf("want ctrl-c to stop quitting once quit is rebound, got %v", game)
+	}
+	if game[keys.Key{Ch: 'p'}] != keys.Pause {
+		t.Errorf("want controls not rebound to keep their keys, got %v", game)
+	}
+
+	moves, _ := cfg.Players["p1"].Moves()
+	if moves[keys.Key{Ch: 'k'}] != player.Up || moves[keys.Key{Key: termbox.KeySpace}] != player.PutBomb {
+		t.Errorf("want the hjkl preset with space added, got %v", moves)
+	}
+
+	for _, bad := range []string{
+		`{"game": {"q": "jump"}}`,
+		`{"game": {"f9": "quit"}}`,
+		`{"players": {"p1": {"preset": "dvorak"}}}`,
+		`{"players": {"p1": {"keys": {"w": "fly"}}}}`,
+	} {
+		if _, err := keys.Load(strings.NewReader(bad)); err == nil {
+			t.Errorf("want an error loading %s", bad)
+		}
+	}
+}
+
+func TestControls(t *testing.T) {
+	local := func(name string, bound map[string]string) config.Entry {
+		return config.Entry{Name: name, Kind: "local", Keys: bound}
+	}
+	tests := []struct {
+		name   string
+		cfg    string
+		roster []config.Entry
+		err    string
+	}{
+		{"default presets", `{}`, []config.Entry{
+			local("p1", nil), {Name: "bot", Kind: "random"}, local("p2", nil), local("p3", nil),
+		}, ""},
+		{"same key for two players", `{}`, []config.Entry{
+			local("p1", map[string]string{"w": "up"}), local("p2", map[string]string{"w": "down"}),
+		}, `player "p2": key w already moves p1`},
+		{"same key in the keys file and a preset", `{"players": {"p2": {"keys": {"up": "bomb"}}}}`, []config.Entry{
+			local("p1", nil), local("p2", nil),
+		}, `player "p2": key up already moves p1`},
+		{"key controlling the game", `{}`, []config.Entry{
+			local("p1", map[string]string{"p": "bomb"}), local("p2", nil),
+		}, `player "p1": key p controls the game (pause)`},
+		{"rebound control", `{"game": {"q": "quit"}}`, []config.Entry{
+			local("p1", map[string]string{"q": "up"}), local("p2", nil),
+		}, `player "p1": key q controls the game (quit)`},
+		{"fourth local player", `{}`, []config.Entry{
+			local("p1", nil), local("p2", nil), local("p3", nil), local("p4", nil),
+		}, `player "p4": only 3 local players have default keys`},
+		{"fourth local player with keys", `{"players": {"p4": {"keys": {"1": "up"}}}}`, []config.Entry{
+			local("p1", nil), local("p2", nil), local("p3", nil), local("p4", nil),
+		}, ""},
+	}
+	for _, tt := range tests {
+		cfg, err := keys.Load(strings.NewReader(tt.cfg))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		game, _ := cfg.GameKeys()
+		controls, err := cfg.Controls(tt.roster, game)
+		switch {
+		case tt.err == "" && err != nil:
+			t.Errorf("%s: want keys bound, got %v", tt.name, err)
+		case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+			t.Errorf("%s: want error containing %q, got %v", tt.name, tt.err, err)
+		}
+		if err != nil {
+			continue
+		}
+		for k, c := range controls {
+			if action, ok := game[k]; ok && action != keys.Follow {
+				t.Errorf("%s: key %s of %s controls the game (%s)", tt.name, k, c.Player, action)
+			}
+		}
+	}
+}
+
+func TestControlsRouteKeys(t *testing.T) {
+	cfg := &keys.Config{}
+	game, _ := cfg.GameKeys()
+	controls, err := cfg.Controls([]config.Entry{
+		{Name: "p1", Kind: "local"},
+		{Name: "p2", Kind: "local", Keys: map[string]string{"i": "up"}},
+	}, game)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		key    keys.Key
+		player string
+		move   player.Move
+	}{
+		{keys.Key{Key: termbox.KeyArrowUp}, "p1", player.Up},
+		{keys.Key{Key: termbox.KeySpace}, "p1", player.PutBomb},
+		{keys.Key{Ch: 'i'}, "p2", player.Up},
+	}
+	for _, tt := range tests {
+		if got := controls[tt.key]; got.Player != tt.player || got.Move != tt.move {
+			t.Errorf("key %s: want %s to %s, got %+v", tt.key, tt.player, tt.move, got)
+		}
+	}
+	if _, ok := controls[keys.Key{Ch: 'w'}]; ok {
+		t.Errorf("want the keys of p2 to replace its default preset, got %v", controls)
+	}
+}
@@ -2,8 +2,10 @@ package logger
 
 import (
 	"fmt"
-	"log"
+	"io"
 	"os"
+	"strings"
+	"time"
 )
 
 type Level uint8
@@ -17,56 +19,118 @@ const (
 	Debug
 )
 
+var levelNames = []string{"panic", "fatal", "error", "warn", "info", "debug"}
+
+func (l Level) String() string {
+	if int(l) < len(levelNames) {
+		return levelNames[l]
+	}
+	return fmt.Sprintf("level(%d)", l)
+}
+
+// ParseLevel reads a level by name, like "info".
+func ParseLevel(name string) (Level, error) {
+	for lvl, n := range levelNames {
+		if strings.EqualFold(n, name) {
+			return Level(lvl), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown log level %q", name)
+}
+
+// Logger writes records at or above its level to a sink, with the fields it
+// was given.
 type Logger struct {
-	l   *log.Logger
-	lvl Level
+	sink   Sink
+	lvl    Level
+	fields []Field
+	// always gets every record, whatever the level.
+	always Sink
 }
 
+// New creates a logger appending text lines to a file.
 func New(prefix, filename string, lvl Level) *Logger {
 	fd, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
 	if err != nil {
 		panic(fmt.Sprintf("creating log file '%s', %v", filename, err))
 	}
-	log.SetOutput(fd)
-	return &Logger{
-		l:   log.New(fd, prefix, log.Lmicroseconds),
-		lvl: lvl,
+	return NewWriter(prefix, fd, lvl)
+}
+
+// NewWriter creates a logger writing text lines to w.
+func NewWriter(prefix string, w io.Writer, lvl Level) *Logger {
+	return NewSinkLogger(NewTextSink(prefix, w), lvl)
+}
+
+// NewSinkLogger creates a logger handing its records to a sink.
+func NewSinkLogger(sink Sink, lvl Level) *Logger {
+	return &Logger{sink: sink, lvl: lvl}
+}
+
+// Sink is where the logger writes.
+func (l *Logger) Sink() Sink {
+	return l.sink
+}
+
+// Capture creates a logger also handing every record to s, even those below
+// its level, like a Ring keeping debug lines for crash dumps.
+func (l *Logger) Capture(s Sink) *Logger {
+	return &Logger{sink: l.sink, lvl: l.lvl, fields: l.fields, always: s}
+}
+
+// With creates a logger adding fields to every record, given as key then
+// value: With("player", "p1", "turn", 12).
+func (l *Logger) With(keyvals ...interface{}) *Logger {
+	fields := make([]Field, len(l.fields), len(l.fields)+len(keyvals)/2)
+	copy(fields, l.fields)
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		fields = append(fields, Field{Key: fmt.Sprint(keyvals[i]), Value: keyvals[i+1]})
 	}
+	return &Logger{sink: l.sink, lvl: l.lvl, fields: fields, always: l.always}
 }
 
-func (l *Logger) Debugf(msg string, arg ...interface{}) {
-	if l.lvl < Debug {
-		return
+// Enabled tells if records of a level are logged, or captured, to skip
+// costly work when they aren't.
+func (l *Logger) Enabled(lvl Level) bool {
+	return l.lvl >= lvl || l.always != nil
+}
+
+func (l *Logger) log(lvl Level, msg string, arg []interface{}) string {
+	if lvl > l.lvl && l.always == nil {
+		return ""
 	}
-	l.l.Printf("[Debug] "+msg, arg...)
+	line := fmt.Sprintf(msg, arg...)
+	r := &Record{Time: time.Now(), Level: lvl, Msg: line, Fields: l.fields}
+	if lvl <= l.lvl {
+		l.sink.Log(r)
+	}
+	if l.always != nil {
+		l.always.Log(r)
+	}
+	return line
+}
+
+func (l *Logger) Debugf(msg string, arg ...interface{}) {
+	l.log(Debug, msg, arg)
 }
 
 func (l *Logger) Infof(msg string, arg ...interface{}) {
-	if l.lvl < Info {
-		return
-	}
-	l.l.Printf("[Info] "+msg, arg...)
+	l.log(Info, msg, arg)
 }
+
 func (l *Logger) Warnf(msg string, arg ...interface{}) {
-	if l.lvl < Warn {
-		return
-	}
-	l.l.Printf("[Warn] "+msg, arg...)
+	l.log(Warn, msg, arg)
 }
+
 func (l *Logger) Errorf(msg string, arg ...interface{}) {
-	if l.lvl < Error {
-		return
-	}
-	l.l.Printf("[Error] "+msg, arg...)
+	l.log(Error, msg, arg)
 }
 
 func (l *Logger) Fatalf(msg string, arg ...interface{}) {
-	if l.lvl < Fatal {
-		return
-	}
-	l.l.Fatalf("[Fatal] "+msg, arg...)
+	l.log(Fatal, msg, arg)
+	os.Exit(1)
 }
 
 func (l *Logger) Panicf(msg string, arg ...interface{}) {
-	l.l.Panicf("[Panic] "+msg, arg...)
+	panic(l.log(Panic, msg, arg))
 }
@@ -0,0 +1,157 @@
+package logger_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/aybabtme/bomberman/logger"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJSONFields(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	log := logger.NewSinkLogger(logger.NewSink(buf, logger.JSON), logger.Info)
+	log.With("turn", 3, "player", "p1").Infof("Powerup! %s", "bomb")
+	log.Debugf("dropped")
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("want one JSON line, got %q: %v", buf.String(), err)
+	}
+	if got["msg"] != "Powerup! bomb" || got["level"] != "info" || got["turn"] != 3.0 || got["player"] != "p1" {
+		t.Errorf("unexpected record %v", got)
+	}
+}
+
+func TestSplitSinkWritesPlayerFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logger")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	main := bytes.NewBuffer(nil)
+	split := logger.NewSplitSink(logger.NewSink(main, logger.Text), "player", dir, "m1-", logger.Text)
+	log := logger.NewSinkLogger(split, logger.Debug)
+	log.With("player", "p1").Infof("moved")
+	log.Infof("turn over")
+	split.Close()
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "m1-p1.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(string(data), "\n") != 1 || strings.Count(main.String(), "\n") != 2 {
+		t.Errorf("want p1's line in its file and both in the main log, got %q and %q", data, main)
+	}
+}
+
+func TestRingCapturesBelowLevel(t *testing.T) {
+	ring := logger.NewRing(2)
+	out := bytes.NewBuffer(nil)
+	log := logger.NewWriter("", out, logger.Info).Capture(ring)
+	log.Debugf("first")
+	log.Debugf("second")
+	log.Infof("third")
+
+	lines := ring.Lines()
+	if len(lines) != 2 || !strings.Contains(lines[0], "second") || !strings.Contains(lines[1], "third") {
+		t.Errorf("want the last two records, got %q", lines)
+	}
+	if strings.Contains(out.String(), "second") {
+		t.Errorf("debug line written at info level: %q", out.String())
+	}
+}
+
+func TestRotatingFileKeepsNewest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logger")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "bomb.log")
+	f, err := logger.OpenRotatingFile(path, 10, 0, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"aaaaaaaa\n", "bbbbbbbb\n", "cccccccc\n", "dddddddd\n"} {
+		if _, err := f.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f.Close()
+
+	rotated, _ := filepath.Glob(path + ".*")
+	sort.Strings(rotated)
+	want := map[string]string{path: "dddddddd\n"}
+	if len(rotated) != 2 {
+		t.Fatalf("want 2 rotated files kept, got %q", rotated)
+	}
+	want[rotated[0]], want[rotated[1]] = "bbbbbbbb\n", "cccccccc\n"
+	for name, want := range want {
+		data, err := ioutil.ReadFile(name)
+		if err != nil || string(data) != want {
+			t.Errorf("%s: want %q, got %q (%v)", name, want, data, err)
+		}
+	}
+}
+
+func TestRotatingFileWritesOnWhenRenameFails(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logger")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "bomb.log")
+	f, err := logger.OpenRotatingFile(path, 10, 0, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	f.Write([]byte("aaaaaaaa\n"))
+	// Moving aside a file that's gone fails.
+	os.Remove(path)
+	for _, line := range []string{"bbbbbbbb\n", "cccccccc\n"} {
+		if _, err := f.Write([]byte(line)); err != nil {
+			t.Fatalf("want writes to go on, got %v", err)
+		}
+	}
+	if data, _ := ioutil.ReadFile(path); !strings.Contains(string(data), "cccccccc") {
+		t.Errorf("want the last line written, got %q", data)
+	}
+}
+
+func TestRotatingFileAgeSurvivesRestart(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logger")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "bomb.log")
+	// The file was started two hours ago, when the last one was moved aside,
+	// but was written to just now.
+	started := time.Now().Add(-2 * time.Hour).UTC().Format("20060102-150405.000000000")
+	for name, data := range map[string]string{path + "." + started: "old\n", path: "recent\n"} {
+		if err := ioutil.WriteFile(name, []byte(data), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f, err := logger.OpenRotatingFile(path, 0, time.Hour, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte("new\n"))
+	f.Close()
+
+	if data, _ := ioutil.ReadFile(path); string(data) != "new\n" {
+		t.Errorf("want the file rotated an hour after it was started, got %q", data)
+	}
+}
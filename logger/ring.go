@@ -0,0 +1,48 @@
+package logger
+
+import (
+	"bytes"
+	"sync"
+)
+
+// Ring keeps the last records it was given, to tell what happened before a
+// crash.
+type Ring struct {
+	mu      sync.Mutex
+	records []*Record
+	next    int
+	full    bool
+}
+
+// NewRing keeps up to size records.
+func NewRing(size int) *Ring {
+	return &Ring{records: make([]*Record, size)}
+}
+
+func (r *Ring) Log(rec *Record) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records[r.next] = rec
+	r.next = (r.next + 1) % len(r.records)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// Lines formats the records kept, oldest first, as text lines.
+func (r *Ring) Lines() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	kept := r.records[:r.next]
+	if r.full {
+		kept = append(append([]*Record{}, r.records[r.next:]...), kept...)
+	}
+	lines := make([]string, len(kept))
+	buf := bytes.NewBuffer(nil)
+	for i, rec := range kept {
+		buf.Reset()
+		encodeText(buf, rec)
+		lines[i] = string(bytes.TrimRight(buf.Bytes(), "\n"))
+	}
+	return lines
+}
@@ -0,0 +1,124 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// rotatedTime is how rotated files are named after the time they were moved
+// aside, which is also when the file after them was started.
+const rotatedTime = "20060102-150405.000000000"
+
+// RotatingFile is a log file that's moved aside once too big or too old.
+// Rotated files are named after the time they were moved aside, file.<time>,
+// and Keep of them are kept.
+type RotatingFile struct {
+	Path string
+	// MaxSize in bytes, and MaxAge, of the file before it's rotated. Zero
+	// means no limit.
+	MaxSize int64
+	MaxAge  time.Duration
+	// Keep that many rotated files; older ones are deleted.
+	Keep int
+
+	mu     sync.Mutex
+	fd     *os.File
+	size   int64
+	opened time.Time
+}
+
+// OpenRotatingFile opens a log file, appending to it.
+func OpenRotatingFile(path string, maxSize int64, maxAge time.Duration, keep int) (*RotatingFile, error) {
+	f := &RotatingFile{Path: path, MaxSize: maxSize, MaxAge: maxAge, Keep: keep}
+	return f, f.open()
+}
+
+func (f *RotatingFile) open() error {
+	fd, err := os.OpenFile(f.Path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0666)
+	if err != nil {
+		return err
+	}
+	info, err := fd.Stat()
+	if err != nil {
+		fd.Close()
+		return err
+	}
+	f.fd, f.size, f.opened = fd, info.Size(), time.Now()
+	if f.size > 0 {
+		// The file was started when the last one was moved aside. If none
+		// was, its age is unknown and it's rotated at the first write.
+		f.opened = time.Time{}
+		if rotated := f.rotated(); len(rotated) > 0 {
+			f.opened = rotated[len(rotated)-1].at
+		}
+	}
+	return nil
+}
+
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	tooBig := f.MaxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.MaxSize
+	tooOld := f.MaxAge > 0 && f.size > 0 && time.Since(f.opened) > f.MaxAge
+	if tooBig || tooOld {
+		// A file that couldn't be moved aside is written to until it can.
+		if err := f.rotate(); err != nil && f.fd == nil {
+			return 0, err
+		}
+	}
+	n, err := f.fd.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// rotate moves the file aside, deletes the rotated files beyond Keep and
+// starts a new file. If the file can't be moved aside, it's opened again.
+func (f *RotatingFile) rotate() error {
+	f.fd.Close()
+	f.fd = nil
+	if f.Keep <= 0 {
+		os.Remove(f.Path)
+		return f.open()
+	}
+	if err := os.Rename(f.Path, f.Path+"."+time.Now().UTC().Format(rotatedTime)); err != nil {
+		if openErr := f.open(); openErr != nil {
+			return openErr
+		}
+		return err
+	}
+	rotated := f.rotated()
+	for len(rotated) > f.Keep {
+		os.Remove(rotated[0].path)
+		rotated = rotated[1:]
+	}
+	return f.open()
+}
+
+type rotatedFile struct {
+	path string
+	at   time.Time
+}
+
+// rotated lists the rotated files, oldest first.
+func (f *RotatingFile) rotated() []rotatedFile {
+	paths, _ := filepath.Glob(f.Path + ".*")
+	var rotated []rotatedFile
+	for _, path := range paths {
+		at, err := time.Parse(rotatedTime, path[len(f.Path)+1:])
+		if err == nil {
+			rotated = append(rotated, rotatedFile{path: path, at: at})
+		}
+	}
+	sort.Slice(rotated, func(i, j int) bool { return rotated[i].at.Before(rotated[j].at) })
+	return rotated
+}
+
+// Close closes the current file.
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.fd.Close()
+}
@@ -0,0 +1,203 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Field is a key and its value, attached to records.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// Record is what's logged by a call to a logger.
+type Record struct {
+	Time   time.Time
+	Level  Level
+	Msg    string
+	Fields []Field
+}
+
+// Get finds the value of a field.
+func (r *Record) Get(key string) (interface{}, bool) {
+	for i := len(r.Fields) - 1; i >= 0; i-- {
+		if r.Fields[i].Key == key {
+			return r.Fields[i].Value, true
+		}
+	}
+	return nil, false
+}
+
+// Sink receives the records of loggers. Sinks are safe to use from many
+// goroutines.
+type Sink interface {
+	Log(r *Record)
+}
+
+// Format is how records are written.
+type Format uint8
+
+const (
+	// Text lines: time, level, message, then key=value fields.
+	Text Format = iota
+	// JSON objects, one per line, with the fields as keys.
+	JSON
+)
+
+// ParseFormat reads a format by name: "text" or "json".
+func ParseFormat(name string) (Format, error) {
+	switch name {
+	case "text":
+		return Text, nil
+	case "json":
+		return JSON, nil
+	}
+	return 0, fmt.Errorf("unknown log format %q", name)
+}
+
+// NewSink creates a sink writing records to w in a format.
+func NewSink(w io.Writer, f Format) Sink {
+	if f == JSON {
+		return &writerSink{w: w, encode: encodeJSON}
+	}
+	return NewTextSink("", w)
+}
+
+// NewTextSink creates a sink writing text lines to w, starting with prefix.
+func NewTextSink(prefix string, w io.Writer) Sink {
+	return &writerSink{w: w, encode: func(buf *bytes.Buffer, r *Record) {
+		buf.WriteString(prefix)
+		encodeText(buf, r)
+	}}
+}
+
+type writerSink struct {
+	mu     sync.Mutex
+	w      io.Writer
+	buf    bytes.Buffer
+	encode func(*bytes.Buffer, *Record)
+}
+
+func (s *writerSink) Log(r *Record) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.buf.Reset()
+	s.encode(&s.buf, r)
+	s.w.Write(s.buf.Bytes())
+}
+
+func encodeText(buf *bytes.Buffer, r *Record) {
+	fmt.Fprintf(buf, "%s [%s] %s", r.Time.Format("15:04:05.000000"), r.Level, r.Msg)
+	for _, f := range r.Fields {
+		fmt.Fprintf(buf, " %s=%v", f.Key, f.Value)
+	}
+	buf.WriteByte('\n')
+}
+
+func encodeJSON(buf *bytes.Buffer, r *Record) {
+	buf.WriteString(`{"time":`)
+	writeJSON(buf, r.Time.Format(time.RFC3339Nano))
+	buf.WriteString(`,"level":`)
+	writeJSON(buf, r.Level.String())
+	buf.WriteString(`,"msg":`)
+	writeJSON(buf, r.Msg)
+	for _, f := range r.Fields {
+		buf.WriteByte(',')
+		writeJSON(buf, f.Key)
+		buf.WriteByte(':')
+		writeJSON(buf, f.Value)
+	}
+	buf.WriteString("}\n")
+}
+
+func writeJSON(buf *bytes.Buffer, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		data, _ = json.Marshal(fmt.Sprint(v))
+	}
+	buf.Write(data)
+}
+
+// Tee hands records to many sinks.
+func Tee(sinks ...Sink) Sink {
+	return tee(sinks)
+}
+
+type tee []Sink
+
+func (t tee) Log(r *Record) {
+	for _, s := range t {
+		s.Log(r)
+	}
+}
+
+// SplitSink also writes the records holding a field, like "player", to a file
+// per value of that field. Files are created in dir as they're needed.
+type SplitSink struct {
+	main   Sink
+	key    string
+	dir    string
+	prefix string
+	format Format
+
+	mu    sync.Mutex
+	files map[string]*os.File
+	sinks map[string]Sink
+}
+
+// NewSplitSink splits the records going to main by the value of key, into
+// files named dir/prefix<value>.log.
+func NewSplitSink(main Sink, key, dir, prefix string, format Format) *SplitSink {
+	return &SplitSink{
+		main:   main,
+		key:    key,
+		dir:    dir,
+		prefix: prefix,
+		format: format,
+		files:  make(map[string]*os.File),
+		sinks:  make(map[string]Sink),
+	}
+}
+
+func (s *SplitSink) Log(r *Record) {
+	s.main.Log(r)
+	v, ok := r.Get(s.key)
+	if !ok {
+		return
+	}
+	name := fmt.Sprint(v)
+
+	s.mu.Lock()
+	sink, ok := s.sinks[name]
+	if !ok {
+		fd, err := os.OpenFile(filepath.Join(s.dir, s.prefix+name+".log"),
+			os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0666)
+		if err == nil {
+			s.files[name] = fd
+			sink = NewSink(fd, s.format)
+		}
+		s.sinks[name] = sink
+	}
+	s.mu.Unlock()
+
+	if sink != nil {
+		sink.Log(r)
+	}
+}
+
+// Close closes the files of every value seen.
+func (s *SplitSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, fd := range s.files {
+		fd.Close()
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"flag"
+	"github.com/aybabtme/bomberman/logger"
+	"github.com/aybabtme/bomberman/match"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// logOptions are the flags saying how and where to log.
+type logOptions struct {
+	file    *string
+	level   *string
+	format  *string
+	dir     *string
+	players *bool
+	maxSize *int64
+	maxAge  *time.Duration
+	keep    *int
+	crash   *string
+
+	lvl logger.Level
+	fmt logger.Format
+}
+
+// logFlags registers the logging flags, with a default level.
+func logFlags(flags *flag.FlagSet, level string) *logOptions {
+	return &logOptions{
+		file:    flags.String("log-file", "bomb.log", "file to log to, - for stderr"),
+		level:   flags.String("log-level", level, "least important logs kept: debug, info, warn, error"),
+		format:  flags.String("log-format", "text", "how logs are written: text or json"),
+		dir:     flags.String("log-dir", "", "directory to write a log file for every match in"),
+		players: flags.Bool("log-players", false, "also write a log file for every player in -log-dir"),
+		maxSize: flags.Int64("log-max-size", 0, "size in MB at which the log file is rotated, 0 for none"),
+		maxAge:  flags.Duration("log-max-age", 0, "age at which the log file is rotated, 0 for none"),
+		keep:    flags.Int("log-keep", 5, "rotated log files kept"),
+		crash:   flags.String("crash-dir", "", "directory to write crash dumps in, with the last lines logged"),
+	}
+}
+
+// open replaces the global logger by the one asked for.
+func (o *logOptions) open() {
+	var err error
+	if o.lvl, err = logger.ParseLevel(*o.level); err != nil {
+		log.Fatalf("%v", err)
+	}
+	if o.fmt, err = logger.ParseFormat(*o.format); err != nil {
+		log.Fatalf("%v", err)
+	}
+	var out io.Writer = os.Stderr
+	if *o.file != "-" {
+		if out, err = logger.OpenRotatingFile(*o.file, *o.maxSize<<20, *o.maxAge, *o.keep); err != nil {
+			log.Fatalf("Opening log file: %v", err)
+		}
+	}
+	log = logger.NewSinkLogger(logger.NewSink(out, o.fmt), o.lvl)
+}
+
+// crashDumps has the engines of matches played under cfg write crash dumps.
+func (o *logOptions) crashDumps(cfg *match.Config) {
+	cfg.CrashDir = *o.crash
+}
+
+// matchLog creates the logger of a match. Its records also go to a file of
+// their own, and to a file per player, when asked for. The returned function
+// closes them.
+func (o *logOptions) matchLog(id string) (*logger.Logger, func()) {
+	matchLog := log.With("match", id)
+	if *o.dir == "" {
+		return matchLog, func() {}
+	}
+	fd, err := os.OpenFile(filepath.Join(*o.dir, "match-"+id+".log"), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0666)
+	if err != nil {
+		log.Errorf("Opening log of match %s: %v", id, err)
+		return matchLog, func() {}
+	}
+	sink := logger.Tee(log.Sink(), logger.NewSink(fd, o.fmt))
+	closers := []func() error{fd.Close}
+	if *o.players {
+		split := logger.NewSplitSink(sink, "player", *o.dir, "match-"+id+"-", o.fmt)
+		sink = split
+		closers = append(closers, split.Close)
+	}
+	return logger.NewSinkLogger(sink, o.lvl).With("match", id), func() {
+		for _, c := range closers {
+			c()
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package match
+
+import (
+	"github.com/aybabtme/bomberman/config"
+	"github.com/aybabtme/bomberman/player/ai"
+	"github.com/aybabtme/bomberman/player/process"
+)
+
+// PolicyMaker creates the policy of a seat. Policies that are also io.Closer
+// are closed at the end of the match.
+type PolicyMaker func(e config.Entry, seed int64) (ai.Policy, error)
+
+var kinds = make(map[string]PolicyMaker)
+
+func init() {
+	for _, kind := range []string{"random", "wandering", "immobile"} {
+		kind := kind
+		Register(kind, func(e config.Entry, seed int64) (ai.Policy, error) {
+			policy, _ := ai.NewPolicy(kind, seed)
+			return policy, nil
+		})
+	}
+	Register("process", func(e config.Entry, seed int64) (ai.Policy, error) {
+		return process.Start(e.Command)
+	})
+}
+
+// Register makes a kind of player able to play headless matches. Call it
+// before matches start, from an init function.
+func Register(kind string, newPolicy PolicyMaker) {
+	kinds[kind] = newPolicy
+}
+
+// Playable tells if a kind of player can play headless matches.
+func Playable(kind string) bool {
+	_, ok := kinds[kind]
+	return ok || kind == External
+}
@@ -0,0 +1,359 @@
+// Package match plays headless matches: no terminal, no clock, and AI players
+// asked for their moves turn by turn so matches run as fast as the CPU allows.
+package match
+
+import (
+	"errors"
+	"fmt"
+	"github.com/aybabtme/bomberman/board"
+	"github.com/aybabtme/bomberman/config"
+	"github.com/aybabtme/bomberman/engine"
+	"github.com/aybabtme/bomberman/game"
+	"github.com/aybabtme/bomberman/logger"
+	"github.com/aybabtme/bomberman/player"
+	"github.com/aybabtme/bomberman/player/ai"
+	"github.com/aybabtme/bomberman/powerup"
+	"github.com/aybabtme/bomberman/stats"
+	"io"
+	"math/rand"
+)
+
+// External is the kind of players whose moves are given by the caller,
+// through the engine, instead of a policy.
+const External = "external"
+
+// Config describes the arena and rules matches are played under.
+type Config struct {
+	// Arena names the generator of the arena, unless Map is set.
+	Arena          string     `json:"arena"`
+	Map            *board.Map `json:"-"`
+	Width          int        `json:"width"`
+	Height         int        `json:"height"`
+	RockFreeRadius int        `json:"rock_free_radius"`
+	RockDensity    float64    `json:"rock_density"`
+
+	// PowerUps hidden under rocks. The seed of every match replaces its seed.
+	PowerUps     powerup.Distribution `json:"powerups"`
+	Rules        engine.Rules         `json:"rules"`
+	FriendlyFire bool                 `json:"friendly_fire"`
+	Vision       game.Vision          `json:"vision"`
+	// MaxTurns ends a match that lasts too long, without a winner.
+	MaxTurns int `json:"max_turns"`
+	// Record keeps the moves of every turn in the result.
+	Record bool `json:"-"`
+	// CrashDir is where the engine writes crash dumps, if set.
+	CrashDir string `json:"-"`
+}
+
+// Validate checks that matches can be set up under the config.
+func (c *Config) Validate() error {
+	if c.Map != nil && (c.RockDensity < 0 || c.RockDensity > 1) {
+		return fmt.Errorf("rock density must be between 0 and 1, got %v", c.RockDensity)
+	}
+	if c.Map == nil {
+		if _, err := board.NewGenerator(c.Arena, c.RockFreeRadius, c.RockDensity); err != nil {
+			return err
+		}
+	}
+	return c.PowerUps.Validate()
+}
+
+// Result is how a match ended.
+type Result struct {
+	Seed int64
+	// Winner is the side left standing, if any.
+	Winner string
+	Turns  int
+	// Truncated matches hit MaxTurns.
+	Truncated bool
+	// DiedAt is the step each dead player died at, by name.
+	DiedAt map[string]int
+	// Stats of every player.
+	Stats []stats.Player `json:",omitempty"`
+	// Moves of every step, by seat, when recorded. Fast players make many
+	// moves a step.
+	Moves [][][]player.Move `json:",omitempty"`
+	// Forfeits of players taken out, when recorded.
+	Forfeits []Forfeit `json:",omitempty"`
+}
+
+// Forfeit is a player taken out of a match before a step, like when their
+// policy crashed or an admin kicked them.
+type Forfeit struct {
+	Step   int    `json:"step"`
+	Player string `json:"player"`
+	Reason string `json:"reason"`
+}
+
+// Draw tells if nobody won.
+func (r *Result) Draw() bool {
+	return r.Winner == ""
+}
+
+// Match is a headless match being played.
+type Match struct {
+	Engine *engine.Engine
+	Stats  *stats.Collector
+	// Players in the order of their seats.
+	Players []*player.State
+
+	cfg      Config
+	seed     int64
+	policies map[*player.State]ai.Policy
+	steps    int
+	diedAt   map[string]int
+	pending  [][]player.Move
+	moves    [][][]player.Move
+	forfeits []Forfeit
+}
+
+// New sets a match up: seats are filled in order from the spawns of the
+// arena. The seed drives the arena, the power-ups and the AI players.
+func New(cfg Config, seats []config.Entry, seed int64, log *logger.Logger) (*Match, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	m := cfg.Map
+	if m == nil {
+		gen, err := board.NewGenerator(cfg.Arena, cfg.RockFreeRadius, cfg.RockDensity)
+		if err != nil {
+			return nil, err
+		}
+		if m, err = gen.Generate(cfg.Width, cfg.Height, rand.New(rand.NewSource(seed))); err != nil {
+			return nil, err
+		}
+	}
+	if len(seats) > len(m.Spawns) {
+		return nil, fmt.Errorf("arena has %d spawns for %d players", len(m.Spawns), len(seats))
+	}
+
+	dist := cfg.PowerUps
+	dist.Seed = seed
+	g := game.NewGame(cfg.Rules.TurnDuration, dist)
+	// Turns are played by Step, not by the clock.
+	g.TurnTick.Stop()
+	g.FriendlyFire = cfg.FriendlyFire
+	g.Vision = cfg.Vision
+	g.Players = make(map[*player.State]player.Player, len(seats))
+
+	match := &Match{
+		cfg:      cfg,
+		seed:     seed,
+		policies: make(map[*player.State]ai.Policy),
+		diedAt:   make(map[string]int),
+	}
+	for i, e := range seats {
+		pState := cfg.Rules.NewState(e.Name, e.Team, m.Spawns[i].X, m.Spawns[i].Y)
+		pState.KeyframeEvery = e.KeyframeEvery
+		for _, mate := range seats {
+			if mate.Team != "" && mate.Team == e.Team && mate.Name != e.Name {
+				pState.Teammates = append(pState.Teammates, mate.Name)
+			}
+		}
+		if e.Kind != External {
+			newPolicy, ok := kinds[e.Kind]
+			if !ok {
+				match.Close()
+				return nil, fmt.Errorf("player %q: kind %q can't play headless", e.Name, e.Kind)
+			}
+			policy, err := newPolicy(e, seed+e.Seed+int64(i))
+			if err != nil {
+				match.Close()
+				return nil, fmt.Errorf("player %q: %v", e.Name, err)
+			}
+			match.policies[pState] = policy
+		}
+		// Moves are given to the engine directly, so players only sit there.
+		g.Players[pState] = ai.NewImmobilePlayer(*pState)
+		match.Players = append(match.Players, pState)
+	}
+
+	match.pending = make([][]player.Move, len(seats))
+
+	b, _ := board.SetupMapBoard(g, m, cfg.RockDensity, rand.New(rand.NewSource(seed)))
+	match.Engine = engine.New(g, b, cfg.Rules, log)
+	match.Engine.DumpCrashes(cfg.CrashDir)
+	match.Stats = stats.Attach(match.Engine)
+	match.Engine.UpdatePlayers()
+	return match, nil
+}
+
+// Move gives a move of an external player for the next step. Players make
+// up to their speed in moves a step; the others are dropped.
+func (m *Match) Move(pState *player.State, move player.Move) {
+	for i, seated := range m.Players {
+		if seated == pState && len(m.pending[i]) < pState.Speed {
+			m.pending[i] = append(m.pending[i], move)
+			m.Engine.Move(pState, move)
+		}
+	}
+}
+
+// Step plays a turn. AI players pick their moves first, as many as their
+// speed until they stay put; moves of external players must be given with
+// Move before.
+func (m *Match) Step() {
+	for _, pState := range m.Players {
+		policy, ok := m.policies[pState]
+		for n := 0; ok && n < pState.Speed && pState.Alive; n++ {
+			move, picked := m.next(pState, policy)
+			if !picked || move == "" {
+				break
+			}
+			m.Move(pState, move)
+		}
+	}
+	m.step()
+}
+
+// Replay plays a recorded step: every seat makes the moves recorded for it,
+// AI players included.
+func (m *Match) Replay(moves [][]player.Move) {
+	for i, pState := range m.Players {
+		for n := 0; i < len(moves) && n < len(moves[i]) && pState.Alive; n++ {
+			m.Move(pState, moves[i][n])
+		}
+	}
+	m.step()
+}
+
+// Restore plays the recorded steps of a match again, with the forfeits among
+// them.
+func (m *Match) Restore(moves [][][]player.Move, forfeits []Forfeit) {
+	for step := 0; step <= len(moves); step++ {
+		for _, f := range forfeits {
+			if f.Step != step {
+				continue
+			}
+			for _, pState := range m.Players {
+				if pState.Name == f.Player {
+					m.Forfeit(pState, errors.New(f.Reason))
+				}
+			}
+		}
+		if step < len(moves) {
+			m.Replay(moves[step])
+		}
+	}
+}
+
+// Forfeit takes a player out of the match before the next step.
+func (m *Match) Forfeit(pState *player.State, reason error) {
+	if !pState.Alive {
+		return
+	}
+	m.Engine.Forfeit(pState, reason)
+	if m.cfg.Record {
+		m.forfeits = append(m.forfeits, Forfeit{Step: m.steps, Player: pState.Name, Reason: reason.Error()})
+	}
+}
+
+// Moves are those of every step so far, by seat, when recorded.
+func (m *Match) Moves() [][][]player.Move {
+	return m.moves
+}
+
+// Forfeits are those of every step so far, when recorded.
+func (m *Match) Forfeits() []Forfeit {
+	return m.forfeits
+}
+
+// Steps is how many steps were played.
+func (m *Match) Steps() int {
+	return m.steps
+}
+
+func (m *Match) step() {
+	if m.cfg.Record {
+		m.moves = append(m.moves, m.pending)
+	}
+	m.pending = make([][]player.Move, len(m.Players))
+	m.Engine.Step()
+	m.Engine.UpdatePlayers()
+	m.steps++
+
+	for _, pState := range m.Players {
+		if _, ok := m.diedAt[pState.Name]; !ok && !pState.Alive {
+			m.diedAt[pState.Name] = m.steps
+		}
+	}
+}
+
+// next asks a policy for the move of its player, who forfeits if it panics.
+func (m *Match) next(pState *player.State, policy ai.Policy) (move player.Move, ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			m.Forfeit(pState, fmt.Errorf("panic: %v", r))
+			ok = false
+		}
+	}()
+	return policy.Next(*pState), true
+}
+
+// Over tells if the match ended, and how.
+func (m *Match) Over() (*Result, bool) {
+	winner, over := m.Engine.Over()
+	truncated := !over && m.cfg.MaxTurns > 0 && m.steps >= m.cfg.MaxTurns
+	if !over && !truncated {
+		return nil, false
+	}
+	return &Result{
+		Seed:      m.seed,
+		Winner:    winner,
+		Turns:     m.steps,
+		Truncated: truncated,
+		DiedAt:    m.diedAt,
+		Stats:     m.Stats.Report(),
+		Moves:     m.moves,
+		Forfeits:  m.forfeits,
+	}, true
+}
+
+// Close stops the policies that run outside the match, like processes.
+func (m *Match) Close() {
+	for _, policy := range m.policies {
+		if c, ok := policy.(io.Closer); ok {
+			c.Close()
+		}
+	}
+}
+
+// Ranks places the players of a match, by name: those who died last rank
+// first, and survivors before them all. Players dying on the same step share
+// their rank.
+func (r *Result) Ranks(names []string) []int {
+	lasted := func(name string) int {
+		if step, ok := r.DiedAt[name]; ok {
+			return step
+		}
+		return r.Turns + 1
+	}
+	ranks := make([]int, len(names))
+	for i, name := range names {
+		for _, other := range names {
+			if lasted(other) > lasted(name) {
+				ranks[i]++
+			}
+		}
+	}
+	return ranks
+}
+
+// Play plays a match until it's over. It needs MaxTurns, since AI players
+// may never finish each other.
+func Play(cfg Config, seats []config.Entry, seed int64, log *logger.Logger) (*Result, error) {
+	if cfg.MaxTurns <= 0 {
+		return nil, fmt.Errorf("need a positive MaxTurns, got %d", cfg.MaxTurns)
+	}
+	m, err := New(cfg, seats, seed, log)
+	if err != nil {
+		return nil, err
+	}
+	defer m.Close()
+	for {
+		m.Step()
+		if res, over := m.Over(); over {
+			return res, nil
+		}
+	}
+}
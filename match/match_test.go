@@ -0,0 +1,236 @@
+package match_test
+
+import (
+	"github.com/aybabtme/bomberman/board"
+	"github.com/aybabtme/bomberman/cell"
+	"github.com/aybabtme/bomberman/config"
+	"github.com/aybabtme/bomberman/engine"
+	"github.com/aybabtme/bomberman/event"
+	"github.com/aybabtme/bomberman/logger"
+	"github.com/aybabtme/bomberman/match"
+	"github.com/aybabtme/bomberman/player"
+	"github.com/aybabtme/bomberman/player/ai"
+	"github.com/aybabtme/bomberman/powerup"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+type panicky struct{}
+
+func (panicky) Next(player.State) player.Move { panic("oops") }
+
+func TestCrashingPolicyForfeits(t *testing.T) {
+	match.Register("panicky", func(config.Entry, int64) (ai.Policy, error) { return panicky{}, nil })
+	m, err := board.LoadMap(strings.NewReader("#####\n#1.2#\n#####\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	seats := []config.Entry{
+		{Name: "p1", Kind: "panicky"},
+		{Name: "p2", Kind: match.External},
+	}
+	res, err := match.Play(match.Config{Map: m, Rules: engine.DefaultRules, MaxTurns: 10}, seats,
+		1, logger.NewWriter("", ioutil.Discard, logger.Error))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Winner != "p2" || res.DiedAt["p1"] != 1 {
+		t.Errorf("want p1 forfeited on the first step, got %+v", res)
+	}
+	if !res.Stats[0].Forfeited {
+		t.Errorf("want p1 marked forfeited, got %+v", res.Stats[0])
+	}
+}
+
+func TestBlastTakesDeadOffBoard(t *testing.T) {
+	m, err := board.LoadMap(strings.NewReader("#####\n#1.2#\n#####\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	seats := []config.Entry{
+		{Name: "p1", Kind: match.External},
+		{Name: "p2", Kind: match.External},
+	}
+	mt, err := match.New(match.Config{Map: m, Rules: engine.DefaultRules, MaxTurns: 50}, seats,
+		1, logger.NewWriter("", ioutil.Discard, logger.Error))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mt.Close()
+	mt.Move(mt.Players[0], player.PutBomb)
+	for mt.Players[0].Alive {
+		if mt.Steps() > 20 {
+			t.Fatal("want p1 killed by their own bomb")
+		}
+		mt.Step()
+	}
+	for _, l := range mt.Engine.Snapshot().Board[1][1].Layers {
+		if l.Kind == cell.Player {
+			t.Errorf("want p1 off the board once dead, got %+v", l)
+		}
+	}
+}
+
+// sender is a player whose moves are sent by the test.
+type sender struct{ moves chan player.Move }
+
+func (s sender) Name() string                { return "sender" }
+func (s sender) Move() <-chan player.Move    { return s.moves }
+func (s sender) Update() chan<- player.State { return make(chan player.State) }
+
+func TestBrokenMoveForfeits(t *testing.T) {
+	m, err := board.LoadMap(strings.NewReader("#####\n#1.2#\n#####\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	seats := []config.Entry{
+		{Name: "p1", Kind: match.External},
+		{Name: "p2", Kind: match.External},
+	}
+	mt, err := match.New(match.Config{Map: m, Rules: engine.DefaultRules, MaxTurns: 50}, seats,
+		1, logger.NewWriter("", ioutil.Discard, logger.Error))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mt.Close()
+	var failed []event.ActionFailed
+	mt.Engine.Events.Subscribe(func(e event.Event) {
+		if e, ok := e.(event.ActionFailed); ok {
+			failed = append(failed, e)
+		}
+	})
+
+	// More bombs than allowed breaks an invariant of the engine.
+	p1 := mt.Players[0]
+	moves := make(chan player.Move, 1)
+	mt.Engine.Game.Players[p1] = sender{moves}
+	p1.Bombs = p1.MaxBomb + 1
+	moves <- player.PutBomb
+	for i := 0; i < 3 && p1.Alive; i++ {
+		mt.Step()
+	}
+	if p1.Alive || len(failed) != 1 {
+		t.Fatalf("want p1 forfeited and their move failed, got alive=%v failed=%+v", p1.Alive, failed)
+	}
+	if res, over := mt.Over(); !over || res.Winner != "p2" {
+		t.Errorf("want p2 to win, got %+v", res)
+	}
+}
+
+func TestNewListsTeammates(t *testing.T) {
+	m, err := board.LoadMap(strings.NewReader("#######\n#1.2.3#\n#######\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	seats := []config.Entry{
+		{Name: "red1", Kind: match.External, Team: "red"},
+		{Name: "solo", Kind: match.External},
+		{Name: "red2", Kind: match.External, Team: "red"},
+	}
+	mt, err := match.New(match.Config{Map: m, Rules: engine.DefaultRules, MaxTurns: 10}, seats,
+		1, logger.NewWriter("", ioutil.Discard, logger.Error))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mt.Close()
+	want := map[string]string{"red1": "red2", "solo": "", "red2": "red1"}
+	for _, pState := range mt.Players {
+		if got := strings.Join(pState.Teammates, ","); got != want[pState.Name] {
+			t.Errorf("%s: want teammates %q, got %q", pState.Name, want[pState.Name], got)
+		}
+	}
+}
+
+type runner struct{}
+
+func (runner) Next(player.State) player.Move { return player.Right }
+
+func TestFastPlayersMoveManyCells(t *testing.T) {
+	match.Register("runner", func(config.Entry, int64) (ai.Policy, error) { return runner{}, nil })
+	m, err := board.LoadMap(strings.NewReader("##########\n#1......2#\n##########\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	seats := []config.Entry{
+		{Name: "p1", Kind: "runner"},
+		{Name: "p2", Kind: match.External},
+	}
+	mt, err := match.New(match.Config{Map: m, Rules: engine.DefaultRules, MaxTurns: 50, Record: true}, seats,
+		1, logger.NewWriter("", ioutil.Discard, logger.Error))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mt.Close()
+
+	// p1 is played by its policy, p2 by moves sent like a remote player.
+	p1, p2 := mt.Players[0], mt.Players[1]
+	p1.Speed, p2.Speed = 2, 2
+	moves := make(chan player.Move, 2)
+	mt.Engine.Game.Players[p2] = sender{moves}
+	moves <- player.Left
+	moves <- player.Left
+
+	x1, x2 := p1.X, p2.X
+	mt.Step()
+	if p1.X-x1 != 2 {
+		t.Errorf("want p1 2 cells further in a turn, from %d, got %d", x1, p1.X)
+	}
+	// Moves read from players are played on the next turn.
+	mt.Step()
+	if x2-p2.X != 2 {
+		t.Errorf("want p2 2 cells further in a turn, from %d, got %d", x2, p2.X)
+	}
+	if got := mt.Moves()[0][0]; len(got) != 2 {
+		t.Errorf("want both moves of p1 recorded, got %v", got)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	for _, cfg := range []match.Config{
+		{Arena: "classic", RockDensity: 1.5},
+		{Arena: "quadrants", RockDensity: -0.1},
+		{Arena: "hexagons"},
+		{Arena: "classic", PowerUps: powerup.Distribution{Weights: map[powerup.Kind]float64{"laser": 1}}},
+	} {
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("want %+v refused", cfg)
+		}
+	}
+	cfg := match.Config{Arena: "classic", RockDensity: 0.5}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("want the classic arena valid, got %v", err)
+	}
+}
+
+func TestTeleporters(t *testing.T) {
+	m, err := board.LoadMap(strings.NewReader("########\n#1@..@2#\n########\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	seats := []config.Entry{
+		{Name: "p1", Kind: match.External},
+		{Name: "p2", Kind: match.External},
+	}
+	mt, err := match.New(match.Config{Map: m, Rules: engine.DefaultRules, MaxTurns: 50}, seats,
+		1, logger.NewWriter("", ioutil.Discard, logger.Error))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mt.Close()
+	p1 := mt.Players[0]
+
+	mt.Move(p1, player.Right)
+	mt.Step()
+	if p1.X != 5 {
+		t.Fatalf("want p1 teleported to (5, 1), got (%d, %d)", p1.X, p1.Y)
+	}
+
+	for _, move := range []player.Move{player.PutBomb, player.Left, player.Left, player.Left} {
+		mt.Move(p1, move)
+		mt.Step()
+	}
+	if p1.X != 2 {
+		t.Errorf("want p1 kept on (2, 1), their bomb is on the other teleporter, got (%d, %d)", p1.X, p1.Y)
+	}
+}
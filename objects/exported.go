@@ -0,0 +1,50 @@
+package objects
+
+import (
+	"github.com/aybabtme/bomberman/cell"
+	"github.com/nsf/termbox-go"
+)
+
+// Unknown is drawn for cells out of sight.
+var Unknown = &TboxObj{
+	&termbox.Cell{
+		Ch: '░',
+		Fg: termbox.ColorBlack,
+		Bg: termbox.ColorDefault,
+	},
+	"Unknown",
+	false,
+	cell.Unknown,
+	"",
+}
+
+var powerUps = []*TboxObj{BombPU, RadiusPU, KickPU, RemotePU, PiercePU, SpeedPU, ShieldPU, SkullPU}
+
+// ForLayer finds the object to draw a cell as players see it, like when
+// watching a match played elsewhere. Players are drawn on the background
+// given for their name.
+func ForLayer(l cell.Layer, playerBg map[string]termbox.Attribute) cell.GameObject {
+	switch l.Kind {
+	case cell.Wall:
+		return Wall
+	case cell.Rock:
+		return Rock
+	case cell.Bomb:
+		return Bomb
+	case cell.Flame:
+		return Flame
+	case cell.Teleporter:
+		return Teleporter
+	case cell.Player:
+		return &TboxPlayer{Name: l.Name, Bg: playerBg[l.Name]}
+	case cell.PowerUp:
+		for _, pu := range powerUps {
+			if pu.powerUp == l.PowerUp {
+				return pu
+			}
+		}
+	case cell.Unknown:
+		return Unknown
+	}
+	return Ground
+}
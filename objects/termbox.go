@@ -22,6 +22,8 @@ var (
 		},
 		"Wall",
 		false,
+		cell.Wall,
+		"",
 	}
 
 	Rock = &TboxObj{
@@ -32,6 +34,8 @@ var (
 		},
 		"Rock",
 		false,
+		cell.Rock,
+		"",
 	}
 
 	Ground = &TboxObj{
@@ -42,6 +46,8 @@ var (
 		},
 		"Ground",
 		true,
+		cell.Ground,
+		"",
 	}
 
 	Bomb = &TboxObj{
@@ -52,6 +58,8 @@ var (
 		},
 		"Bomb",
 		false,
+		cell.Bomb,
+		"",
 	}
 
 	Flame = &TboxObj{
@@ -62,6 +70,20 @@ var (
 		},
 		"Flame",
 		true,
+		cell.Flame,
+		"",
+	}
+
+	Teleporter = &TboxObj{
+		&termbox.Cell{
+			Ch: '◎',
+			Fg: termbox.ColorCyan,
+			Bg: termbox.ColorDefault,
+		},
+		"Teleporter",
+		true,
+		cell.Teleporter,
+		"",
 	}
 
 	BombPU = &TboxObj{
@@ -72,6 +94,8 @@ var (
 		},
 		"PowerUp(Bomb)",
 		true,
+		cell.PowerUp,
+		"bomb",
 	}
 
 	RadiusPU = &TboxObj{
@@ -82,6 +106,80 @@ var (
 		},
 		"PowerUp(Radius)",
 		true,
+		cell.PowerUp,
+		"radius",
+	}
+
+	KickPU = &TboxObj{
+		&termbox.Cell{
+			Ch: 'Ⓚ',
+			Fg: termbox.ColorYellow,
+			Bg: termbox.ColorMagenta,
+		},
+		"PowerUp(Kick)",
+		true,
+		cell.PowerUp,
+		"kick",
+	}
+
+	RemotePU = &TboxObj{
+		&termbox.Cell{
+			Ch: 'Ⓓ',
+			Fg: termbox.ColorYellow,
+			Bg: termbox.ColorMagenta,
+		},
+		"PowerUp(Remote)",
+		true,
+		cell.PowerUp,
+		"remote",
+	}
+
+	PiercePU = &TboxObj{
+		&termbox.Cell{
+			Ch: 'Ⓟ',
+			Fg: termbox.ColorYellow,
+			Bg: termbox.ColorMagenta,
+		},
+		"PowerUp(Pierce)",
+		true,
+		cell.PowerUp,
+		"pierce",
+	}
+
+	SpeedPU = &TboxObj{
+		&termbox.Cell{
+			Ch: 'Ⓢ',
+			Fg: termbox.ColorYellow,
+			Bg: termbox.ColorMagenta,
+		},
+		"PowerUp(Speed)",
+		true,
+		cell.PowerUp,
+		"speed",
+	}
+
+	ShieldPU = &TboxObj{
+		&termbox.Cell{
+			Ch: 'Ⓔ',
+			Fg: termbox.ColorYellow,
+			Bg: termbox.ColorMagenta,
+		},
+		"PowerUp(Shield)",
+		true,
+		cell.PowerUp,
+		"shield",
+	}
+
+	SkullPU = &TboxObj{
+		&termbox.Cell{
+			Ch: '☠',
+			Fg: termbox.ColorWhite,
+			Bg: termbox.ColorBlack,
+		},
+		"PowerUp(Skull)",
+		true,
+		cell.PowerUp,
+		"skull",
 	}
 )
 
@@ -89,6 +187,9 @@ type TboxObj struct {
 	*termbox.Cell
 	name        string
 	traversable bool
+	kind        cell.Kind
+	// powerUp is the kind of power-up this is, if it's one.
+	powerUp string
 }
 
 func (to *TboxObj) Draw(x, y int) {
@@ -104,12 +205,33 @@ func (t *TboxObj) String() string {
 	return t.name
 }
 
+func (t *TboxObj) Kind() cell.Kind {
+	return t.kind
+}
+
+func (t *TboxObj) Describe(l *cell.Layer, now int) {
+	l.PowerUp = t.powerUp
+}
+
+// TeamColors are the backgrounds given to players of each team, in order.
+var TeamColors = []termbox.Attribute{
+	termbox.ColorMagenta,
+	termbox.ColorBlue,
+	termbox.ColorCyan,
+	termbox.ColorGreen,
+}
+
 type TboxPlayer struct {
 	Name string
+	// Bg is the background the player is drawn with. Defaults to magenta.
+	Bg termbox.Attribute
 }
 
 func (t TboxPlayer) Draw(x, y int) {
 	fg, bg := termbox.ColorWhite, termbox.ColorMagenta
+	if t.Bg != termbox.ColorDefault {
+		bg = t.Bg
+	}
 	termbox.SetCell(x*2, y, []rune(t.Name)[0], fg, bg)
 	termbox.SetCell(x*2+1, y, []rune(t.Name)[1], fg, bg)
 }
@@ -121,3 +243,7 @@ func (t TboxPlayer) Traversable() bool {
 func (t *TboxPlayer) String() string {
 	return t.Name
 }
+
+func (t *TboxPlayer) Kind() cell.Kind {
+	return cell.Player
+}
@@ -0,0 +1,51 @@
+package ai
+
+import (
+	"github.com/aybabtme/bomberman/player"
+	"math/rand"
+)
+
+// Policy picks the moves of a player turn by turn, for matches driven faster
+// than real time. Next returns an empty move to stay put.
+type Policy interface {
+	Next(state player.State) player.Move
+}
+
+// NewPolicy creates the policy of an AI kind: "random", "wandering" or
+// "immobile".
+func NewPolicy(kind string, seed int64) (Policy, bool) {
+	switch kind {
+	case "random":
+		return &RandomPolicy{rand.New(rand.NewSource(seed)), true}, true
+	case "wandering":
+		return &RandomPolicy{rand.New(rand.NewSource(seed)), false}, true
+	case "immobile":
+		return ImmobilePolicy{}, true
+	}
+	return nil, false
+}
+
+// RandomPolicy moves like RandomPlayer, or WanderingPlayer when it doesn't
+// place bombs: half of the turns it makes a random move.
+type RandomPolicy struct {
+	rnd   *rand.Rand
+	bombs bool
+}
+
+func (r *RandomPolicy) Next(state player.State) player.Move {
+	moves := []player.Move{player.Up, player.Down, player.Left, player.Right, player.PutBomb}
+	if !r.bombs {
+		moves = moves[:4]
+	}
+	if n := r.rnd.Intn(2 * len(moves)); n < len(moves) {
+		return moves[n]
+	}
+	return player.Move("")
+}
+
+// ImmobilePolicy never moves.
+type ImmobilePolicy struct{}
+
+func (ImmobilePolicy) Next(state player.State) player.Move {
+	return player.Move("")
+}
@@ -2,6 +2,7 @@ package ai
 
 import (
 	"github.com/aybabtme/bomberman/player"
+	"github.com/aybabtme/bomberman/powerup"
 	"math/rand"
 	"time"
 )
@@ -10,16 +11,17 @@ type RandomPlayer struct {
 	state   player.State
 	update  chan player.State
 	outMove chan player.Move
+	crashed <-chan error
 }
 
 func NewRandomPlayer(state player.State, seed int64) player.Player {
 	r := &RandomPlayer{
 		state:   state,
 		update:  make(chan player.State),
-		outMove: make(chan player.Move, 1),
+		outMove: make(chan player.Move, powerup.MaxSpeed),
 	}
 
-	go func() {
+	r.crashed = player.Guard(func() {
 		rnd := rand.New(rand.NewSource(seed))
 		for {
 			var m player.Move
@@ -40,7 +42,7 @@ func NewRandomPlayer(state player.State, seed int64) player.Player {
 			}
 			r.outMove <- m
 		}
-	}()
+	})
 
 	return r
 }
@@ -56,3 +58,7 @@ func (r *RandomPlayer) Move() <-chan player.Move {
 func (r *RandomPlayer) Update() chan<- player.State {
 	return r.update
 }
+
+func (r *RandomPlayer) Crashed() <-chan error {
+	return r.crashed
+}
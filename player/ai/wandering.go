@@ -2,6 +2,7 @@ package ai
 
 import (
 	"github.com/aybabtme/bomberman/player"
+	"github.com/aybabtme/bomberman/powerup"
 	"math/rand"
 	"time"
 )
@@ -10,16 +11,17 @@ type WanderingPlayer struct {
 	state   player.State
 	update  chan player.State
 	outMove chan player.Move
+	crashed <-chan error
 }
 
 func NewWanderingPlayer(state player.State, seed int64) *WanderingPlayer {
 	w := &WanderingPlayer{
 		state:   state,
 		update:  make(chan player.State),
-		outMove: make(chan player.Move, 1),
+		outMove: make(chan player.Move, powerup.MaxSpeed),
 	}
 
-	go func() {
+	w.crashed = player.Guard(func() {
 		rnd := rand.New(rand.NewSource(seed))
 		for {
 			var m player.Move
@@ -38,7 +40,7 @@ func NewWanderingPlayer(state player.State, seed int64) *WanderingPlayer {
 			}
 			w.outMove <- m
 		}
-	}()
+	})
 
 	return w
 }
@@ -54,3 +56,7 @@ func (w *WanderingPlayer) Move() <-chan player.Move {
 func (w *WanderingPlayer) Update() chan<- player.State {
 	return w.update
 }
+
+func (w *WanderingPlayer) Crashed() <-chan error {
+	return w.crashed
+}
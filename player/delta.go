@@ -0,0 +1,40 @@
+package player
+
+import (
+	"github.com/aybabtme/bomberman/cell"
+)
+
+// Delta is what changed on the board, as seen by a player, since the
+// previous update. Players opt into deltas by setting KeyframeEvery in their
+// state; they then only receive a full Board in keyframes.
+type Delta struct {
+	Turn int
+	// Keyframe deltas come with the full board in State.Board, and nothing
+	// else. Changes start over from there.
+	Keyframe bool
+	Cells    []CellChange
+	// Spawned and Removed list the objects that appeared on, or disappeared
+	// from, the changed cells.
+	Spawned, Removed []Object
+	// Moved lists the players seen moving from one cell to another.
+	Moved []PlayerMove
+}
+
+// CellChange is the new content of a cell.
+type CellChange struct {
+	X, Y int
+	Cell *cell.Exported
+}
+
+// Object is a layer at a position on the board.
+type Object struct {
+	X, Y  int
+	Layer cell.Layer
+}
+
+// PlayerMove is a player moving between two cells.
+type PlayerMove struct {
+	Name         string
+	FromX, FromY int
+	X, Y         int
+}
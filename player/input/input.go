@@ -2,6 +2,7 @@ package input
 
 import (
 	"github.com/aybabtme/bomberman/player"
+	"github.com/aybabtme/bomberman/powerup"
 )
 
 type InputPlayer struct {
@@ -11,6 +12,7 @@ type InputPlayer struct {
 	update  chan player.State
 	inMove  <-chan player.Move
 	outMove chan player.Move
+	crashed <-chan error
 }
 
 func NewInputPlayer(state player.State, input <-chan player.Move) player.Player {
@@ -18,10 +20,10 @@ func NewInputPlayer(state player.State, input <-chan player.Move) player.Player
 		state:   state,
 		update:  make(chan player.State),
 		inMove:  input,
-		outMove: make(chan player.Move, 1), // Rate-limiting to 1 move per turn
+		outMove: make(chan player.Move, powerup.MaxSpeed),
 	}
 
-	go func() {
+	i.crashed = player.Guard(func() {
 		for i.state.Alive {
 			select {
 			case move := <-i.inMove:
@@ -29,12 +31,17 @@ func NewInputPlayer(state player.State, input <-chan player.Move) player.Player
 			case i.state = <-i.update:
 			}
 		}
-	}()
+	})
 
 	return i
 }
 
+// forwardMove keeps as many moves as the player makes per turn, and drops
+// the others.
 func (i *InputPlayer) forwardMove(move player.Move) {
+	if len(i.outMove) >= i.state.Speed {
+		return
+	}
 	select {
 	case i.outMove <- move:
 	default:
@@ -53,3 +60,7 @@ func (i *InputPlayer) Move() <-chan player.Move {
 func (i *InputPlayer) Update() chan<- player.State {
 	return i.update
 }
+
+func (i *InputPlayer) Crashed() <-chan error {
+	return i.crashed
+}
@@ -1,6 +1,7 @@
 package player
 
 import (
+	"fmt"
 	"github.com/aybabtme/bomberman/cell"
 	"time"
 )
@@ -9,12 +10,30 @@ type State struct {
 	Turn                      int
 	TurnDuration              time.Duration
 	Name                      string
+	Team                      string
+	Teammates                 []string
 	X, Y, LastX, LastY        int
 	Bombs, MaxBomb, MaxRadius int
-	Alive                     bool
-	Board                     [][]*cell.Exported
-	GameObject                cell.GameObject
-	Message                   string
+	// Power-ups picked so far.
+	CanKick, CanRemote, Pierce bool
+	Speed, Shield, CursedTurns int
+	Alive                      bool
+	Board                      [][]*cell.Exported
+	// KeyframeEvery, when positive, asks for the board as a Delta every
+	// turn, and in full only every that many turns.
+	KeyframeEvery int
+	Delta         *Delta
+	GameObject    cell.GameObject
+	Message       string
+}
+
+// Side is the name of the team a player fights for. Players without a team
+// fight on their own.
+func (s *State) Side() string {
+	if s.Team == "" {
+		return s.Name
+	}
+	return s.Team
 }
 
 type Move string
@@ -25,6 +44,8 @@ const (
 	Left    = Move("left")
 	Right   = Move("right")
 	PutBomb = Move("bomb")
+	// Detonate sets off the player's bombs early, if they hold a remote.
+	Detonate = Move("detonate")
 )
 
 type Player interface {
@@ -32,3 +53,24 @@ type Player interface {
 	Move() <-chan Move
 	Update() chan<- State
 }
+
+// Crasher is a player whose implementation can crash. Players that crashed
+// are forfeited.
+type Crasher interface {
+	Crashed() <-chan error
+}
+
+// Guard runs f in a goroutine, recovering it if it panics. The panic is sent
+// on the returned channel, for players to return it from Crashed.
+func Guard(f func()) <-chan error {
+	crashed := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				crashed <- fmt.Errorf("panic: %v", r)
+			}
+		}()
+		f()
+	}()
+	return crashed
+}
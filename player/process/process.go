@@ -0,0 +1,98 @@
+// Package process plays bots running in their own process. Every turn, the bot
+// reads its state as a line of JSON on stdin and answers with a line holding
+// its move, like "up" or "bomb". An empty line stays put. Bots answer every
+// state, in order: an answer to an older state is dropped.
+package process
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"github.com/aybabtme/bomberman/player"
+	"io"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Bot is a running bot process.
+type Bot struct {
+	cmd     *exec.Cmd
+	in      io.WriteCloser
+	enc     *json.Encoder
+	replies chan reply
+	// sent counts the states sent, to tell which one a reply answers.
+	sent int
+}
+
+// reply is the move of a bot, answering the n-th state it was sent.
+type reply struct {
+	n    int
+	move player.Move
+}
+
+// Start runs a bot: the program, then its arguments.
+func Start(command []string) (*Bot, error) {
+	if len(command) == 0 {
+		return nil, fmt.Errorf("no command to run")
+	}
+	cmd := exec.Command(command[0], command[1:]...)
+	in, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	b := &Bot{
+		cmd:     cmd,
+		in:      in,
+		enc:     json.NewEncoder(in),
+		replies: make(chan reply, 1),
+	}
+	go func() {
+		defer close(b.replies)
+		scan := bufio.NewScanner(out)
+		for n := 1; scan.Scan(); n++ {
+			b.replies <- reply{n, player.Move(strings.TrimSpace(scan.Text()))}
+		}
+	}()
+	return b, nil
+}
+
+// Next sends the state to the bot and waits for its move. Bots that take
+// longer than a turn to answer stay put, and their late answer is dropped
+// when it comes.
+func (b *Bot) Next(state player.State) player.Move {
+	b.sent++
+	if err := b.enc.Encode(state); err != nil {
+		return player.Move("")
+	}
+	timeout := time.After(state.TurnDuration)
+	for {
+		select {
+		case r, ok := <-b.replies:
+			if !ok {
+				return player.Move("")
+			}
+			if r.n == b.sent {
+				return r.move
+			}
+		case <-timeout:
+			return player.Move("")
+		}
+	}
+}
+
+// Close stops the bot.
+func (b *Bot) Close() error {
+	b.in.Close()
+	b.cmd.Process.Kill()
+	b.cmd.Wait()
+	return nil
+}
@@ -0,0 +1,58 @@
+package process_test
+
+import (
+	"github.com/aybabtme/bomberman/player"
+	"github.com/aybabtme/bomberman/player/process"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+// bot answers up, then down too late, then left.
+const bot = `
+n=0
+while read state; do
+	n=$((n+1))
+	case $n in
+	1) echo up ;;
+	2) sleep 0.3; echo down ;;
+	*) echo left ;;
+	esac
+done`
+
+func TestBotAnswersInTime(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no sh to run the bot")
+	}
+	b, err := process.Start([]string{"sh", "-c", bot})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state := player.State{Name: "p1", TurnDuration: time.Second}
+	if m := b.Next(state); m != player.Up {
+		t.Errorf("want up, got %q", m)
+	}
+	state.TurnDuration = 50 * time.Millisecond
+	if m := b.Next(state); m != "" {
+		t.Errorf("want a bot too slow to stay put, got %q", m)
+	}
+	state.TurnDuration = time.Second
+	if m := b.Next(state); m != player.Left {
+		t.Errorf("want the late answer dropped and left, got %q", m)
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		b.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("want the bot stopped")
+	}
+	if m := b.Next(state); m != "" {
+		t.Errorf("want a stopped bot to stay put, got %q", m)
+	}
+}
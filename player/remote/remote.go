@@ -0,0 +1,119 @@
+// Package remote plays humans at a terminal elsewhere, running the client
+// command. Every turn, the client reads its state as a line of JSON and
+// answers with lines holding its moves, like "up" or "bomb".
+package remote
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"github.com/aybabtme/bomberman/player"
+	"github.com/aybabtme/bomberman/powerup"
+	"net"
+	"strings"
+	"sync/atomic"
+)
+
+// Player is a client connected to play a seat.
+type Player struct {
+	state player.State
+	conn  net.Conn
+	// speed is how many moves of the client are kept per turn, as the
+	// last state sent says.
+	speed int32
+
+	// Comms
+	update  chan player.State
+	moves   chan player.Move
+	crashed chan error
+}
+
+// Accept waits for a client to connect on addr, and plays a seat for it.
+func Accept(state player.State, addr string) (*Player, error) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	defer l.Close()
+	conn, err := l.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return NewPlayer(state, conn), nil
+}
+
+// NewPlayer plays a seat for a client already connected.
+func NewPlayer(state player.State, conn net.Conn) *Player {
+	p := &Player{
+		state:   state,
+		conn:    conn,
+		update:  make(chan player.State, 1),
+		moves:   make(chan player.Move, powerup.MaxSpeed),
+		crashed: make(chan error, 1),
+		speed:   int32(state.Speed),
+	}
+	go p.send()
+	go func() {
+		p.crashed <- p.receive()
+	}()
+	return p
+}
+
+// send streams the states of the player to the client, until it's gone.
+func (p *Player) send() {
+	enc := json.NewEncoder(p.conn)
+	for state := range p.update {
+		atomic.StoreInt32(&p.speed, int32(state.Speed))
+		// What players look like on the terminal isn't state.
+		state.GameObject = nil
+		if err := enc.Encode(state); err != nil {
+			return
+		}
+	}
+}
+
+// receive forwards the moves of the client, and tells why it left.
+func (p *Player) receive() error {
+	scan := bufio.NewScanner(p.conn)
+	for scan.Scan() {
+		if len(p.moves) >= int(atomic.LoadInt32(&p.speed)) {
+			continue
+		}
+		select {
+		case p.moves <- player.Move(strings.TrimSpace(scan.Text())):
+		default:
+			// Drop it
+		}
+	}
+	if err := scan.Err(); err != nil {
+		return fmt.Errorf("client disconnected: %v", err)
+	}
+	return fmt.Errorf("client disconnected")
+}
+
+// Addr is where the client connected from.
+func (p *Player) Addr() net.Addr {
+	return p.conn.RemoteAddr()
+}
+
+func (p *Player) Name() string {
+	return p.state.Name
+}
+
+func (p *Player) Move() <-chan player.Move {
+	return p.moves
+}
+
+func (p *Player) Update() chan<- player.State {
+	return p.update
+}
+
+// Crashed tells when the client disconnects, which forfeits the player.
+func (p *Player) Crashed() <-chan error {
+	return p.crashed
+}
+
+// Close disconnects the client.
+func (p *Player) Close() error {
+	return p.conn.Close()
+}
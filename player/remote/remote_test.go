@@ -0,0 +1,46 @@
+package remote_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/aybabtme/bomberman/player"
+	"github.com/aybabtme/bomberman/player/remote"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestPlayerTalksToClient(t *testing.T) {
+	server, client := net.Pipe()
+	p := remote.NewPlayer(player.State{Name: "p2", Alive: true, Speed: 1}, server)
+	defer p.Close()
+
+	p.Update() <- player.State{Name: "p2", Turn: 3, Alive: true, Speed: 2}
+	state := player.State{}
+	if err := json.NewDecoder(client).Decode(&state); err != nil {
+		t.Fatal(err)
+	}
+	if state.Turn != 3 {
+		t.Errorf("want the state of turn 3, got %+v", state)
+	}
+
+	// Fast players make many moves a turn.
+	fmt.Fprint(client, "bomb\nup\n")
+	for _, want := range []player.Move{player.PutBomb, player.Up} {
+		select {
+		case m := <-p.Move():
+			if m != want {
+				t.Errorf("want the move of the client, %q, got %q", want, m)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("move %q of the client never came", want)
+		}
+	}
+
+	client.Close()
+	select {
+	case <-p.Crashed():
+	case <-time.After(time.Second):
+		t.Fatal("want the player to crash once the client is gone")
+	}
+}
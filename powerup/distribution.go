@@ -0,0 +1,197 @@
+package powerup
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// Distribution says how many power-ups of each kind to hide under rocks.
+type Distribution struct {
+	// Counts are exact numbers of power-ups to place, by kind.
+	Counts map[Kind]int
+	// Weights split WeightedTotal power-ups among kinds, in proportion to
+	// their weight.
+	Weights       map[Kind]float64
+	WeightedTotal int
+	// FairQuadrants places the same number of each kind in every quadrant of
+	// the board. What can't be split equally goes to random quadrants, at
+	// most one extra per quadrant.
+	FairQuadrants bool
+	Seed          int64
+}
+
+// Spot is a position on the board.
+type Spot struct {
+	X, Y int
+}
+
+// Placed is a power-up hidden at a spot.
+type Placed struct {
+	Kind Kind
+	Spot
+}
+
+// Placement reports where power-ups were hidden.
+type Placement struct {
+	Placed []Placed
+	// Unplaced counts the power-ups for which no rock was left, by kind.
+	Unplaced map[Kind]int
+	// Quadrants counts the power-ups placed in each quadrant, by kind.
+	// Quadrants are numbered top-left, top-right, bottom-left, bottom-right.
+	Quadrants [4]map[Kind]int
+}
+
+// Validate checks that the distribution only asks for power-ups of the
+// Catalogue.
+func (d Distribution) Validate() error {
+	for kind := range d.Counts {
+		if _, ok := Lookup(kind); !ok {
+			return fmt.Errorf("unknown power-up %q", kind)
+		}
+	}
+	for kind := range d.Weights {
+		if _, ok := Lookup(kind); !ok {
+			return fmt.Errorf("unknown power-up %q", kind)
+		}
+	}
+	return nil
+}
+
+// Want computes how many power-ups of each kind the distribution asks for.
+// Weighted power-ups are rounded using the largest remainder, so they always
+// add up to WeightedTotal. Kinds missing from the Catalogue get nothing.
+func (d Distribution) Want() map[Kind]int {
+	want := make(map[Kind]int)
+	for kind, n := range d.Counts {
+		if n > 0 {
+			want[kind] += n
+		}
+	}
+
+	var sum float64
+	for _, pu := range Catalogue {
+		if w := d.Weights[pu.Kind]; w > 0 {
+			sum += w
+		}
+	}
+	if sum == 0 || d.WeightedTotal <= 0 {
+		return want
+	}
+
+	type share struct {
+		kind Kind
+		rest float64
+	}
+	shares := []share{}
+	given := 0
+	for _, pu := range Catalogue {
+		w := d.Weights[pu.Kind]
+		if w <= 0 {
+			continue
+		}
+		exact := float64(d.WeightedTotal) * w / sum
+		whole := int(math.Floor(exact))
+		want[pu.Kind] += whole
+		given += whole
+		shares = append(shares, share{pu.Kind, exact - float64(whole)})
+	}
+	sort.SliceStable(shares, func(i, j int) bool { return shares[i].rest > shares[j].rest })
+	for i := 0; given < d.WeightedTotal; i++ {
+		want[shares[i%len(shares)].kind]++
+		given++
+	}
+	return want
+}
+
+// Place hides the power-ups among the rocks of a board of the given size,
+// uniformly at random. Power-ups for which there is no rock left are reported
+// as unplaced.
+func (d Distribution) Place(rocks []Spot, width, height int) *Placement {
+	rnd := rand.New(rand.NewSource(d.Seed))
+	p := &Placement{Unplaced: make(map[Kind]int)}
+	for q := range p.Quadrants {
+		p.Quadrants[q] = make(map[Kind]int)
+	}
+
+	quadrantOf := func(s Spot) int {
+		q := 0
+		if s.X >= width/2 {
+			q++
+		}
+		if s.Y >= height/2 {
+			q += 2
+		}
+		return q
+	}
+
+	// Rocks free to hide something, shuffled, per quadrant.
+	var free [4][]Spot
+	if d.FairQuadrants {
+		for _, s := range rocks {
+			q := quadrantOf(s)
+			free[q] = append(free[q], s)
+		}
+	} else {
+		free[0] = append(free[0], rocks...)
+	}
+	for q := range free {
+		rnd.Shuffle(len(free[q]), func(i, j int) { free[q][i], free[q][j] = free[q][j], free[q][i] })
+	}
+
+	place := func(kind Kind, q int) {
+		if len(free[q]) == 0 {
+			p.Unplaced[kind]++
+			return
+		}
+		s := free[q][0]
+		free[q] = free[q][1:]
+		p.Placed = append(p.Placed, Placed{kind, s})
+		p.Quadrants[quadrantOf(s)][kind]++
+	}
+
+	want := d.Want()
+	for _, pu := range Catalogue {
+		n := want[pu.Kind]
+		if !d.FairQuadrants {
+			for i := 0; i < n; i++ {
+				place(pu.Kind, 0)
+			}
+			continue
+		}
+		for q := 0; q < 4; q++ {
+			for i := 0; i < n/4; i++ {
+				place(pu.Kind, q)
+			}
+		}
+		for _, q := range rnd.Perm(4)[:n%4] {
+			place(pu.Kind, q)
+		}
+	}
+	return p
+}
+
+// String describes the placement, for debugging.
+func (p *Placement) String() string {
+	buf := bytes.NewBuffer(nil)
+	fmt.Fprintf(buf, "%d power-ups placed", len(p.Placed))
+	for _, pu := range Catalogue {
+		if n := p.Unplaced[pu.Kind]; n != 0 {
+			fmt.Fprintf(buf, ", %d %s unplaced", n, pu.Kind)
+		}
+	}
+	for q, counts := range p.Quadrants {
+		fmt.Fprintf(buf, "\n  quadrant %d:", q)
+		for _, pu := range Catalogue {
+			if n := counts[pu.Kind]; n != 0 {
+				fmt.Fprintf(buf, " %s=%d", pu.Kind, n)
+			}
+		}
+	}
+	for _, placed := range p.Placed {
+		fmt.Fprintf(buf, "\n  %s at (%d, %d)", placed.Kind, placed.X, placed.Y)
+	}
+	return buf.String()
+}
@@ -0,0 +1,105 @@
+package powerup_test
+
+import (
+	"github.com/aybabtme/bomberman/powerup"
+	"testing"
+)
+
+func grid(width, height int) []powerup.Spot {
+	spots := []powerup.Spot{}
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			spots = append(spots, powerup.Spot{X: x, Y: y})
+		}
+	}
+	return spots
+}
+
+func TestWantSplitsWeightsExactly(t *testing.T) {
+	d := powerup.Distribution{
+		Counts:        map[powerup.Kind]int{powerup.Skull: 2},
+		Weights:       map[powerup.Kind]float64{powerup.Bomb: 1, powerup.Radius: 1, powerup.Kick: 1},
+		WeightedTotal: 10,
+	}
+	want := d.Want()
+
+	total := 0
+	for _, n := range want {
+		total += n
+	}
+	if total != 12 {
+		t.Fatalf("want 12 power-ups, got %d: %v", total, want)
+	}
+	if want[powerup.Skull] != 2 {
+		t.Errorf("want 2 skulls, got %d", want[powerup.Skull])
+	}
+	for _, kind := range []powerup.Kind{powerup.Bomb, powerup.Radius, powerup.Kick} {
+		if n := want[kind]; n < 3 || n > 4 {
+			t.Errorf("want 3 or 4 %s, got %d", kind, n)
+		}
+	}
+}
+
+func TestPlaceIsFairAcrossQuadrants(t *testing.T) {
+	d := powerup.Distribution{
+		Counts:        map[powerup.Kind]int{powerup.Bomb: 8, powerup.Radius: 6},
+		FairQuadrants: true,
+		Seed:          42,
+	}
+	p := d.Place(grid(10, 10), 10, 10)
+
+	if len(p.Placed) != 14 || len(p.Unplaced) != 0 {
+		t.Fatalf("want 14 placed and none unplaced, got %v", p)
+	}
+	for q, counts := range p.Quadrants {
+		if counts[powerup.Bomb] != 2 {
+			t.Errorf("quadrant %d: want 2 bombs, got %d", q, counts[powerup.Bomb])
+		}
+		if n := counts[powerup.Radius]; n < 1 || n > 2 {
+			t.Errorf("quadrant %d: want 1 or 2 radius, got %d", q, n)
+		}
+	}
+
+	seen := make(map[powerup.Spot]bool)
+	for _, placed := range p.Placed {
+		if seen[placed.Spot] {
+			t.Errorf("two power-ups at %v", placed.Spot)
+		}
+		seen[placed.Spot] = true
+	}
+}
+
+func TestPlaceIsSeeded(t *testing.T) {
+	d := powerup.Distribution{Counts: map[powerup.Kind]int{powerup.Bomb: 5}, Seed: 7}
+	first := d.Place(grid(6, 6), 6, 6).String()
+	second := d.Place(grid(6, 6), 6, 6).String()
+	if first != second {
+		t.Errorf("same seed gave different placements:\n%s\n%s", first, second)
+	}
+}
+
+func TestPlaceReportsUnplaced(t *testing.T) {
+	d := powerup.Distribution{Counts: map[powerup.Kind]int{powerup.Bomb: 5}}
+	p := d.Place(grid(1, 3), 1, 3)
+	if len(p.Placed) != 3 || p.Unplaced[powerup.Bomb] != 2 {
+		t.Errorf("want 3 placed and 2 unplaced, got %v", p)
+	}
+}
+
+func TestWantIgnoresUnknownKinds(t *testing.T) {
+	d := powerup.Distribution{
+		Weights:       map[powerup.Kind]float64{"laser": 3, powerup.Bomb: 1},
+		WeightedTotal: 4,
+	}
+	if err := d.Validate(); err == nil {
+		t.Errorf("want lasers refused")
+	}
+	if want := d.Want(); want[powerup.Bomb] != 4 {
+		t.Errorf("want every weighted power-up a bomb, got %v", want)
+	}
+
+	d.Weights = map[powerup.Kind]float64{"laser": 1}
+	if want := d.Want(); len(want) != 0 {
+		t.Errorf("want nothing when no kind is known, got %v", want)
+	}
+}
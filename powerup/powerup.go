@@ -0,0 +1,78 @@
+package powerup
+
+import (
+	"github.com/aybabtme/bomberman/cell"
+	"github.com/aybabtme/bomberman/objects"
+	"github.com/aybabtme/bomberman/player"
+)
+
+// Kind names a type of power-up.
+type Kind string
+
+const (
+	Bomb   = Kind("bomb")
+	Radius = Kind("radius")
+	Kick   = Kind("kick")
+	Remote = Kind("remote")
+	Pierce = Kind("pierce")
+	Speed  = Kind("speed")
+	Shield = Kind("shield")
+	Skull  = Kind("skull")
+)
+
+const (
+	// MaxSpeed caps the number of moves a player can make per turn.
+	MaxSpeed = 3
+	// SkullTurns is how long the skull curse lasts.
+	SkullTurns = 50
+)
+
+// PowerUp is hidden under rocks and changes the state of whoever picks it.
+type PowerUp struct {
+	Kind   Kind
+	Object cell.GameObject
+	// Apply gives the power-up to the player who picked it.
+	Apply func(*player.State)
+}
+
+// Catalogue lists every power-up that can appear on the board.
+var Catalogue = []PowerUp{
+	{Bomb, objects.BombPU, func(s *player.State) { s.MaxBomb++ }},
+	{Radius, objects.RadiusPU, func(s *player.State) { s.MaxRadius++ }},
+	{Kick, objects.KickPU, func(s *player.State) { s.CanKick = true }},
+	{Remote, objects.RemotePU, func(s *player.State) { s.CanRemote = true }},
+	{Pierce, objects.PiercePU, func(s *player.State) { s.Pierce = true }},
+	{Speed, objects.SpeedPU, func(s *player.State) {
+		if s.Speed < MaxSpeed {
+			s.Speed++
+		}
+	}},
+	{Shield, objects.ShieldPU, func(s *player.State) { s.Shield++ }},
+	{Skull, objects.SkullPU, func(s *player.State) { s.CursedTurns = SkullTurns }},
+}
+
+// Lookup finds the power-up of the given kind.
+func Lookup(k Kind) (PowerUp, bool) {
+	for _, pu := range Catalogue {
+		if pu.Kind == k {
+			return pu, true
+		}
+	}
+	return PowerUp{}, false
+}
+
+// Find finds the power-up drawn as the given object.
+func Find(o cell.GameObject) (PowerUp, bool) {
+	for _, pu := range Catalogue {
+		if pu.Object == o {
+			return pu, true
+		}
+	}
+	return PowerUp{}, false
+}
+
+// Is tells if the object is a power-up.
+func Is(o cell.GameObject) bool {
+	_, ok := Find(o)
+	return ok
+}
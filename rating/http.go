@@ -0,0 +1,35 @@
+package rating
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// Handler serves the store as JSON:
+//
+//	GET /leaderboard lists the ratings, best first
+//	GET /players/<name> lists the rated matches of a player
+func (s *Store) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/leaderboard", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, s.Leaderboard())
+	})
+	mux.HandleFunc("/players/", func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, "/players/")
+		history := s.History(name)
+		if history == nil {
+			http.NotFound(w, r)
+			return
+		}
+		writeJSON(w, history)
+	})
+	return mux
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
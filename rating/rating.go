@@ -0,0 +1,192 @@
+// Package rating rates bots from the placements of their matches, with Elo or
+// TrueSkill. Matches of more than two players count as duels between every
+// pair of them.
+package rating
+
+import (
+	"fmt"
+	"math"
+)
+
+// Rating is the skill of a player. Elo only uses Mu.
+type Rating struct {
+	Name    string  `json:"name"`
+	Mu      float64 `json:"mu"`
+	Sigma   float64 `json:"sigma,omitempty"`
+	Matches int     `json:"matches"`
+	// Score ranks the leaderboard: the Elo rating, or what TrueSkill is
+	// confident the player is at least worth.
+	Score float64 `json:"score"`
+}
+
+// System updates ratings after a match.
+type System interface {
+	Name() string
+	// New is the rating of a player who never played.
+	New(name string) Rating
+	// Update rates players from their ranks in a match, 0 being first.
+	// Players sharing a rank drew.
+	Update(ratings []Rating, ranks []int) []Rating
+}
+
+// NewSystem finds a rating system by name: "elo" or "trueskill".
+func NewSystem(name string) (System, error) {
+	switch name {
+	case "elo":
+		return Elo{K: 32, Initial: 1500}, nil
+	case "trueskill":
+		return NewTrueSkill(), nil
+	}
+	return nil, fmt.Errorf("unknown rating system %q", name)
+}
+
+// outcome is the score of a against b: 1 for a win, 0.5 for a draw.
+func outcome(rankA, rankB int) float64 {
+	switch {
+	case rankA < rankB:
+		return 1
+	case rankA == rankB:
+		return 0.5
+	}
+	return 0
+}
+
+//////////////
+// Elo
+
+// Elo rates players with the Elo system. K is spread over the opponents of a
+// match, so a match is worth as much with 2 or 4 players.
+type Elo struct {
+	K       float64
+	Initial float64
+}
+
+func (e Elo) Name() string { return "elo" }
+
+func (e Elo) New(name string) Rating {
+	return Rating{Name: name, Mu: e.Initial, Score: e.Initial}
+}
+
+func (e Elo) Update(ratings []Rating, ranks []int) []Rating {
+	updated := append([]Rating{}, ratings...)
+	k := e.K / float64(len(ratings)-1)
+	for i := range ratings {
+		for j := range ratings {
+			if i == j {
+				continue
+			}
+			expected := 1 / (1 + math.Pow(10, (ratings[j].Mu-ratings[i].Mu)/400))
+			updated[i].Mu += k * (outcome(ranks[i], ranks[j]) - expected)
+		}
+		updated[i].Matches++
+		updated[i].Score = updated[i].Mu
+	}
+	return updated
+}
+
+//////////////
+// TrueSkill
+
+// TrueSkill rates players with the TrueSkill system, which also tracks how
+// sure it is of every rating.
+type TrueSkill struct {
+	Mu, Sigma, Beta, Tau float64
+	// DrawProbability is how often evenly matched players draw.
+	DrawProbability float64
+}
+
+// NewTrueSkill uses the usual parameters of TrueSkill.
+func NewTrueSkill() TrueSkill {
+	return TrueSkill{
+		Mu:              25,
+		Sigma:           25.0 / 3,
+		Beta:            25.0 / 6,
+		Tau:             25.0 / 300,
+		DrawProbability: 0.1,
+	}
+}
+
+func (t TrueSkill) Name() string { return "trueskill" }
+
+func (t TrueSkill) New(name string) Rating {
+	return Rating{Name: name, Mu: t.Mu, Sigma: t.Sigma, Score: t.Mu - 3*t.Sigma}
+}
+
+func (t TrueSkill) Update(ratings []Rating, ranks []int) []Rating {
+	updated := append([]Rating{}, ratings...)
+	n := float64(len(ratings) - 1)
+	epsilon := inverseCDF((t.DrawProbability+1)/2) * math.Sqrt2 * t.Beta
+
+	for i, a := range ratings {
+		variance := a.Sigma*a.Sigma + t.Tau*t.Tau
+		var dMu, shrink float64
+		for j, b := range ratings {
+			if i == j {
+				continue
+			}
+			c := math.Sqrt(2*t.Beta*t.Beta + variance + b.Sigma*b.Sigma + t.Tau*t.Tau)
+			diff, e := (a.Mu-b.Mu)/c, epsilon/c
+			var v, w float64
+			switch outcome(ranks[i], ranks[j]) {
+			case 1:
+				v, w = vWin(diff, e), wWin(diff, e)
+			case 0:
+				v, w = -vWin(-diff, e), wWin(-diff, e)
+			default:
+				v, w = vDraw(diff, e), wDraw(diff, e)
+			}
+			dMu += variance / c * v
+			shrink += variance / (c * c) * w
+		}
+		updated[i].Mu += dMu / n
+		updated[i].Sigma = math.Sqrt(variance * math.Max(1-shrink/n, 1e-4))
+		updated[i].Matches++
+		updated[i].Score = updated[i].Mu - 3*updated[i].Sigma
+	}
+	return updated
+}
+
+func pdf(x float64) float64 {
+	return math.Exp(-x*x/2) / math.Sqrt(2*math.Pi)
+}
+
+func cdf(x float64) float64 {
+	return math.Erfc(-x/math.Sqrt2) / 2
+}
+
+func inverseCDF(p float64) float64 {
+	return math.Sqrt2 * math.Erfinv(2*p-1)
+}
+
+func vWin(t, e float64) float64 {
+	denom := cdf(t - e)
+	if denom < 1e-12 {
+		return e - t
+	}
+	return pdf(t-e) / denom
+}
+
+func wWin(t, e float64) float64 {
+	v := vWin(t, e)
+	return v * (v + t - e)
+}
+
+func vDraw(t, e float64) float64 {
+	denom := cdf(e-t) - cdf(-e-t)
+	if denom < 1e-12 {
+		if t < 0 {
+			return -t - e
+		}
+		return -t + e
+	}
+	return (pdf(-e-t) - pdf(e-t)) / denom
+}
+
+func wDraw(t, e float64) float64 {
+	denom := cdf(e-t) - cdf(-e-t)
+	if denom < 1e-12 {
+		return 1
+	}
+	v := vDraw(t, e)
+	return v*v + ((e-t)*pdf(e-t)+(e+t)*pdf(e+t))/denom
+}
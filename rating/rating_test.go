@@ -0,0 +1,109 @@
+package rating_test
+
+import (
+	"encoding/json"
+	"github.com/aybabtme/bomberman/rating"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSystemsRewardWinners(t *testing.T) {
+	for _, name := range []string{"elo", "trueskill"} {
+		sys, err := rating.NewSystem(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		before := []rating.Rating{sys.New("a"), sys.New("b"), sys.New("c")}
+		after := sys.Update(before, []int{0, 1, 1})
+		if after[0].Score <= before[0].Score || after[2].Score >= after[0].Score {
+			t.Errorf("%s: want the winner to climb, got %+v", name, after)
+		}
+		if after[1].Mu != after[2].Mu {
+			t.Errorf("%s: want players sharing a rank to be rated alike, got %+v", name, after)
+		}
+		if name == "trueskill" && after[0].Sigma >= before[0].Sigma {
+			t.Errorf("%s: want more certainty after a match, got %+v", name, after)
+		}
+	}
+}
+
+func TestStorePersists(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rating")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "ratings.jsonl")
+
+	sys, _ := rating.NewSystem("elo")
+	s, err := rating.Open(filename, sys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := rating.Match{ID: "m1", Players: []string{"a", "b"}, Ranks: []int{1, 0}}
+	if ok, err := s.Record(m); !ok || err != nil {
+		t.Fatalf("want match rated, got %v, %v", ok, err)
+	}
+	if ok, _ := s.Record(m); ok {
+		t.Errorf("want the same match rated once")
+	}
+	want := s.Leaderboard()
+	s.Close()
+
+	s, err = rating.Open(filename, sys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	got := s.Leaderboard()
+	if len(got) != 2 || got[0] != want[0] || got[0].Name != "b" {
+		t.Errorf("want %+v after reopening, got %+v", want, got)
+	}
+
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/players/a", nil))
+	var history []rating.Entry
+	if err := json.NewDecoder(rec.Body).Decode(&history); err != nil || len(history) != 1 {
+		t.Errorf("want a's match, got %v, %+v", err, history)
+	}
+
+	if _, err := rating.Open(filename, rating.NewTrueSkill()); err == nil {
+		t.Errorf("want history rated with another system to be refused")
+	}
+}
+
+func TestStoreDropsCutLastLine(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rating")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "ratings.jsonl")
+
+	sys, _ := rating.NewSystem("elo")
+	s, err := rating.Open(filename, sys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Record(rating.Match{ID: "m1", Players: []string{"a", "b"}, Ranks: []int{1, 0}})
+	s.Close()
+	// A crash while the next match was written.
+	fd, _ := os.OpenFile(filename, os.O_WRONLY|os.O_APPEND, 0644)
+	fd.WriteString(`{"id":"m2","players":["a",`)
+	fd.Close()
+
+	for i := 0; i < 2; i++ {
+		if s, err = rating.Open(filename, sys); err != nil {
+			t.Fatalf("want the cut line dropped, got %v", err)
+		}
+		s.Record(rating.Match{ID: "m2", Players: []string{"a", "b"}, Ranks: []int{0, 1}})
+		history := s.History("a")
+		s.Close()
+		if len(history) != 2 {
+			t.Errorf("want m1 and m2 rated, got %+v", history)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package rating
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Match is the outcome of a match to rate.
+type Match struct {
+	// ID tells matches apart, so the same match is never rated twice.
+	ID      string    `json:"id"`
+	Time    time.Time `json:"time"`
+	Players []string  `json:"players"`
+	// Ranks of the players, 0 being first. Players sharing a rank drew.
+	Ranks []int `json:"ranks"`
+}
+
+// Entry is a line of the history: a rated match and the ratings of its
+// players after it.
+type Entry struct {
+	Match
+	System string   `json:"system"`
+	After  []Rating `json:"after"`
+}
+
+// Store keeps ratings in a file, as the history of the matches that led to
+// them, one JSON entry per line. It's safe to use from many goroutines.
+type Store struct {
+	system System
+
+	mu      sync.Mutex
+	fd      *os.File
+	ratings map[string]Rating
+	seen    map[string]bool
+	history []Entry
+}
+
+// Open reads the history in filename, creating it if needed. The history must
+// have been rated with the same system.
+func Open(filename string, system System) (*Store, error) {
+	fd, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	s := &Store{
+		system:  system,
+		fd:      fd,
+		ratings: make(map[string]Rating),
+		seen:    make(map[string]bool),
+	}
+
+	r := bufio.NewReader(fd)
+	var read int64
+	for line := 1; ; line++ {
+		data, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			// A last line without its newline was cut short while being
+			// written, so its match was never rated: drop it.
+			if err = fd.Truncate(read); err == nil {
+				break
+			}
+		}
+		if err != nil {
+			fd.Close()
+			return nil, err
+		}
+		read += int64(len(data))
+
+		var e Entry
+		if err := json.Unmarshal(data, &e); err != nil {
+			fd.Close()
+			return nil, fmt.Errorf("%s:%d: %v", filename, line, err)
+		}
+		if e.System != system.Name() {
+			fd.Close()
+			return nil, fmt.Errorf("%s:%d: rated with %s, not %s", filename, line, e.System, system.Name())
+		}
+		s.apply(e)
+	}
+	return s, nil
+}
+
+func (s *Store) apply(e Entry) {
+	for _, r := range e.After {
+		s.ratings[r.Name] = r
+	}
+	s.seen[e.ID] = true
+	s.history = append(s.history, e)
+}
+
+// Record rates a match and saves it. It tells false if the match was already
+// rated.
+func (s *Store) Record(m Match) (bool, error) {
+	if len(m.Players) < 2 || len(m.Players) != len(m.Ranks) {
+		return false, fmt.Errorf("match %s: need a rank for each of at least 2 players", m.ID)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.seen[m.ID] {
+		return false, nil
+	}
+	if m.Time.IsZero() {
+		m.Time = time.Now()
+	}
+
+	before := make([]Rating, len(m.Players))
+	for i, name := range m.Players {
+		before[i] = s.rating(name)
+	}
+	e := Entry{Match: m, System: s.system.Name(), After: s.system.Update(before, m.Ranks)}
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return false, err
+	}
+	if _, err := s.fd.Write(append(data, '\n')); err != nil {
+		return false, err
+	}
+	s.apply(e)
+	return true, nil
+}
+
+func (s *Store) rating(name string) Rating {
+	if r, ok := s.ratings[name]; ok {
+		return r
+	}
+	return s.system.New(name)
+}
+
+// Leaderboard lists the ratings of every player, best first.
+func (s *Store) Leaderboard() []Rating {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	board := make([]Rating, 0, len(s.ratings))
+	for _, r := range s.ratings {
+		board = append(board, r)
+	}
+	sort.Slice(board, func(i, j int) bool {
+		if board[i].Score != board[j].Score {
+			return board[i].Score > board[j].Score
+		}
+		return board[i].Name < board[j].Name
+	})
+	return board
+}
+
+// History lists the rated matches a player played, oldest first.
+func (s *Store) History(name string) []Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var played []Entry
+	for _, e := range s.history {
+		for _, p := range e.Players {
+			if p == name {
+				played = append(played, e)
+				break
+			}
+		}
+	}
+	return played
+}
+
+// Close closes the history file.
+func (s *Store) Close() error {
+	return s.fd.Close()
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/aybabtme/bomberman/config"
+	"github.com/aybabtme/bomberman/engine"
+	"github.com/aybabtme/bomberman/event"
+	"github.com/aybabtme/bomberman/match"
+	"github.com/aybabtme/bomberman/rating"
+	"net/http"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+// openRatings opens the rating history, if one is given.
+func openRatings(filename, system string) (*rating.Store, error) {
+	if filename == "" {
+		return nil, nil
+	}
+	sys, err := rating.NewSystem(system)
+	if err != nil {
+		return nil, err
+	}
+	return rating.Open(filename, sys)
+}
+
+// trackDeaths remembers the turn every player died on, to rank them once the
+// match is over.
+func trackDeaths(eng *engine.Engine) map[string]int {
+	diedAt := make(map[string]int)
+	eng.Events.Subscribe(func(e event.Event) {
+		switch e := e.(type) {
+		case event.PlayerDied:
+			diedAt[e.Player] = e.Turn
+		case event.PlayerForfeited:
+			diedAt[e.Player] = e.Turn
+		}
+	})
+	return diedAt
+}
+
+// rateMatch rates the players of a finished match.
+func rateMatch(store *rating.Store, eng *engine.Engine, roster *config.Roster, diedAt map[string]int) error {
+	winner, over := eng.Over()
+	if !over {
+		return nil
+	}
+	res := &match.Result{Winner: winner, Turns: eng.Game.Turn(), DiedAt: diedAt}
+	names := make([]string, len(roster.Players))
+	for i, e := range roster.Players {
+		names[i] = e.Name
+	}
+	_, err := store.Record(rating.Match{
+		ID:      fmt.Sprintf("local-%d", time.Now().UnixNano()),
+		Players: names,
+		Ranks:   res.Ranks(names),
+	})
+	return err
+}
+
+// runRatings prints the leaderboard, or serves it over HTTP.
+func runRatings(args []string) {
+	flags := flag.NewFlagSet("ratings", flag.ExitOnError)
+	logOpts := logFlags(flags, "info")
+	filename := flags.String("ratings", "ratings.jsonl", "file holding the rating history")
+	system := flags.String("rating-system", "elo", "how players are rated: elo or trueskill")
+	addr := flags.String("http", "", "serve the leaderboard as JSON on this address, like :8080")
+	flags.Parse(args)
+	logOpts.open()
+
+	store, err := openRatings(*filename, *system)
+	if err != nil {
+		log.Fatalf("Opening ratings: %v", err)
+	}
+	defer store.Close()
+
+	if *addr != "" {
+		log.Infof("Serving ratings on %s.", *addr)
+		if err := http.ListenAndServe(*addr, store.Handler()); err != nil {
+			log.Fatalf("Serving ratings: %v", err)
+		}
+		return
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "#\tplayer\tscore\tmu\tsigma\tmatches")
+	for i, r := range store.Leaderboard() {
+		fmt.Fprintf(tw, "%d\t%s\t%.1f\t%.1f\t%.2f\t%d\n", i+1, r.Name, r.Score, r.Mu, r.Sigma, r.Matches)
+	}
+	tw.Flush()
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"github.com/aybabtme/bomberman/stats"
+	"github.com/nsf/termbox-go"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// resultTitle tells who won.
+func resultTitle(winner string) string {
+	if winner == "" {
+		return "Draw! All players are dead."
+	}
+	return winner + " won!"
+}
+
+// showResults tells how the match ended and what everyone did, until a key
+// that isn't a move is pressed.
+func showResults(title string, report []stats.Player, evChan <-chan termbox.Event) {
+	lines := []string{title, ""}
+	lines = append(lines, strings.Split(strings.TrimRight(stats.Table(report), "\n"), "\n")...)
+	lines = append(lines, "", "Press Esc to quit.")
+
+	termbox.Clear(termbox.ColorDefault, termbox.ColorDefault)
+	for y, line := range lines {
+		drawText(1, 1+y, line)
+	}
+	termbox.Flush()
+
+	for ev := range evChan {
+		if ev.Type == termbox.EventKey || ev.Type == termbox.EventError {
+			return
+		}
+	}
+}
+
+func drawText(x, y int, text string) {
+	for i, r := range []rune(text) {
+		termbox.SetCell(x+i, y, r, termbox.ColorDefault, termbox.ColorDefault)
+	}
+}
+
+// writeStats exports the stats of a match, as CSV or JSON depending on the
+// extension of the file.
+func writeStats(filename string, report []stats.Player) error {
+	fd, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if filepath.Ext(filename) == ".csv" {
+		err = stats.WriteCSV(fd, report)
+	} else {
+		err = stats.WriteJSON(fd, report)
+	}
+	if cerr := fd.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -2,6 +2,7 @@ package scheduler
 
 import (
 	"container/heap"
+	"strings"
 )
 
 // Scheduler registers actions that will occur in the future.
@@ -33,6 +34,11 @@ func (s *Scheduler) Register(act Action, startsIn int) {
 	heap.Push(s.events, e)
 }
 
+// Now is the current turn.
+func (s *Scheduler) Now() int {
+	return s.now
+}
+
 // HasNext is true as long as there are events registered to happen
 func (s *Scheduler) HasNext() bool {
 	return !s.events.Empty()
@@ -65,14 +71,16 @@ func (s *Scheduler) NextTurn() {
 
 // DoTurn will invoke the given lambda with all the actions occuring at this
 // turn, along with the delta since the action has begun.  If this is the first
-// turn of an action, delta will be 0.
+// turn of an action, delta will be 0. An action that fails isn't continued,
+// but the other actions of the turn still happen; the errors are returned
+// together as Errors.
 func (s *Scheduler) DoTurn(eachAction func(a Action, delta int) error) error {
-	var err error
+	var errs Errors
 	for i := range s.current {
 		ev := (s.current)[i]
-		err = eachAction(ev.Action, ev.TurnsDone)
-		if err != nil {
-			break
+		if err := eachAction(ev.Action, ev.TurnsDone); err != nil {
+			errs = append(errs, err)
+			continue
 		}
 
 		ev.TurnsDone++
@@ -81,7 +89,21 @@ func (s *Scheduler) DoTurn(eachAction func(a Action, delta int) error) error {
 			heap.Push(s.events, ev)
 		}
 	}
-	return err
+	if len(errs) != 0 {
+		return errs
+	}
+	return nil
+}
+
+// Errors are those of the actions that failed during a turn.
+type Errors []error
+
+func (errs Errors) Error() string {
+	msgs := make([]string, len(errs))
+	for i, err := range errs {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
 }
 
 // Action takes place at a time for a duration
@@ -42,3 +42,23 @@ func ExampleScheduler_simple() {
 	// Bye
 	// Still there?
 }
+
+func ExampleScheduler_DoTurn_errors() {
+	s := scheduler.NewScheduler()
+	s.Register(PrintAction("Fails"), 1)
+	s.Register(PrintAction("Still happens"), 1)
+
+	s.NextTurn()
+	err := s.DoTurn(func(a scheduler.Action, turn int) error {
+		if a == PrintAction("Fails") {
+			return fmt.Errorf("failed")
+		}
+		fmt.Println(a.(PrintAction))
+		return nil
+	})
+	fmt.Println(err)
+
+	// Output:
+	// Still happens
+	// failed
+}
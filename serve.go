@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"github.com/aybabtme/bomberman/engine"
+	"github.com/aybabtme/bomberman/match"
+	"github.com/aybabtme/bomberman/powerup"
+	"github.com/aybabtme/bomberman/server"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// runServe hosts many matches at once, created through an admin API, until
+// interrupted.
+func runServe(args []string) {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	logOpts := logFlags(flags, "info")
+	configFile := flags.String("config", "", "JSON file overriding the default server config")
+	addr := flags.String("addr", "localhost:8080", "address to serve the admin API on")
+	dir := flags.String("dir", "serve", "directory to write finished and saved matches in")
+	grace := flags.Duration("grace", 30*time.Second, "how long running matches may finish on shutdown before they're saved")
+	ratings := flags.String("ratings", "", "file holding the rating history, to rate every finished match")
+	system := flags.String("rating-system", "elo", "how players are rated: elo or trueskill")
+	flags.Parse(args)
+	logOpts.open()
+
+	cfg := server.Config{
+		Match: match.Config{
+			Arena:          "classic",
+			Width:          MaxX + 2,
+			Height:         MaxY + 2,
+			RockFreeRadius: RockFreeArea,
+			RockDensity:    RockDensity,
+			PowerUps:       powerup.Distribution{Counts: make(map[powerup.Kind]int)},
+			Rules:          engine.DefaultRules,
+		},
+		MaxMatches: 64,
+		MaxPlayers: 4,
+		MaxTurns:   3000,
+	}
+	for kind, n := range powerUps {
+		cfg.Match.PowerUps.Counts[kind] = n
+	}
+	if *configFile != "" {
+		fd, err := os.Open(*configFile)
+		if err != nil {
+			log.Fatalf("Opening server config: %v", err)
+		}
+		err = json.NewDecoder(fd).Decode(&cfg)
+		fd.Close()
+		if err != nil {
+			log.Fatalf("Reading server config: %v", err)
+		}
+	}
+	if err := cfg.Match.Validate(); err != nil {
+		log.Fatalf("Checking server config: %v", err)
+	}
+	cfg.MatchLog = logOpts.matchLog
+	logOpts.crashDumps(&cfg.Match)
+	var err error
+	if cfg.Ratings, err = openRatings(*ratings, *system); err != nil {
+		log.Fatalf("Opening ratings: %v", err)
+	}
+	if cfg.Ratings != nil {
+		defer cfg.Ratings.Close()
+	}
+
+	srv, err := server.New(cfg, *dir, log)
+	if err != nil {
+		log.Fatalf("Creating server: %v", err)
+	}
+	resumed, err := srv.Resume()
+	if err != nil {
+		log.Errorf("Resuming saved matches: %v", err)
+	}
+	log.Infof("Resumed %d saved matches.", resumed)
+
+	admin := &http.Server{Addr: *addr, Handler: srv.Handler()}
+	go func() {
+		log.Infof("Serving admin API on %s.", *addr)
+		if err := admin.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("Serving admin API: %v", err)
+		}
+	}()
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	<-sig
+	log.Infof("Shutting down, letting matches finish for %v.", *grace)
+
+	ctx, cancel := context.WithTimeout(context.Background(), *grace)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Infof("Saved the matches still running.")
+	}
+	admin.Shutdown(context.Background())
+}
@@ -0,0 +1,166 @@
+package server
+
+import (
+	"encoding/json"
+	"github.com/aybabtme/bomberman/engine"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Handler serves the admin API as JSON:
+//
+//	GET  /matches lists the matches, oldest first
+//	POST /matches creates a match from a Spec
+//	GET  /matches/<id> tells how a match is doing
+//	GET  /matches/<id>/state gives the turn, board, players and bombs
+//	GET  /matches/<id>/board gives the board, or what ?player=<name> sees
+//	GET  /matches/<id>/players gives the players
+//	GET  /matches/<id>/stats tells what the players did so far
+//	POST /matches/<id>/pause, /resume or /abort controls a match
+//	POST /matches/<id>/step plays a turn of a paused match
+//	POST /matches/<id>/speed?turn=100ms changes how long turns last
+//	POST /matches/<id>/kick/<player> takes a player out
+//	GET  /matches/<id>/replay downloads the record of a match
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/matches", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			writeJSON(w, http.StatusOK, s.List())
+		case "POST":
+			// Only JSON is read, so pages browsed by the admin can't create
+			// matches with simple requests.
+			if ct, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); ct != "application/json" {
+				http.Error(w, "matches are created from application/json", http.StatusUnsupportedMediaType)
+				return
+			}
+			spec := Spec{}
+			if err := json.NewDecoder(r.Body).Decode(&spec); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			status, err := s.Create(spec)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+			writeJSON(w, http.StatusCreated, status)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+	mux.HandleFunc("/matches/", func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/matches/"), "/", 3)
+		id, action := parts[0], ""
+		if len(parts) > 1 {
+			action = parts[1]
+		}
+
+		want := "GET"
+		switch action {
+		case "pause", "resume", "step", "speed", "abort", "kick":
+			want = "POST"
+		}
+		if r.Method != want {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var (
+			v   interface{}
+			err error
+		)
+		switch action {
+		case "":
+			v, err = s.status(id)
+		case "board":
+			if name := r.URL.Query().Get("player"); name != "" {
+				v, err = s.View(id, name)
+				break
+			}
+			fallthrough
+		case "state", "players":
+			var snap *engine.Snapshot
+			if snap, err = s.Snapshot(id); err == nil {
+				switch action {
+				case "state":
+					v = snap
+				case "board":
+					v = snap.Board
+				default:
+					v = snap.Players
+				}
+			}
+		case "stats":
+			v, err = s.Stats(id)
+		case "pause":
+			err = s.Pause(id)
+		case "resume":
+			err = s.Continue(id)
+		case "step":
+			err = s.Step(id)
+		case "speed":
+			d, err2 := time.ParseDuration(r.URL.Query().Get("turn"))
+			if err2 != nil {
+				http.Error(w, "speed needs a turn duration: "+err2.Error(), http.StatusBadRequest)
+				return
+			}
+			err = s.Speed(id, d)
+		case "abort":
+			err = s.Abort(id)
+		case "kick":
+			if len(parts) < 3 || parts[2] == "" {
+				http.Error(w, "kick needs a player", http.StatusBadRequest)
+				return
+			}
+			err = s.Kick(id, parts[2])
+		case "replay":
+			if v, err = s.Replay(id); err == nil {
+				w.Header().Set("Content-Disposition", `attachment; filename="`+id+`.json"`)
+			}
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		if v == nil {
+			v, _ = s.status(id)
+		}
+		writeJSON(w, http.StatusOK, v)
+	})
+	return mux
+}
+
+func (s *Server) status(id string) (Status, error) {
+	status, ok := s.Get(id)
+	if !ok {
+		return status, ErrNotFound
+	}
+	return status, nil
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	code := http.StatusBadRequest
+	switch err {
+	case ErrNotFound, ErrNoPlayer:
+		code = http.StatusNotFound
+	case ErrNotRunning, ErrNotPaused:
+		code = http.StatusConflict
+	case ErrFull, ErrClosed:
+		code = http.StatusServiceUnavailable
+	}
+	http.Error(w, err.Error(), code)
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
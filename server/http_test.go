@@ -0,0 +1,168 @@
+package server_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/aybabtme/bomberman/cell"
+	"github.com/aybabtme/bomberman/engine"
+	"github.com/aybabtme/bomberman/server"
+	"github.com/aybabtme/bomberman/stats"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func call(t *testing.T, method, url, body string, want int, v interface{}) {
+	req, err := http.NewRequest(method, url, bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != want {
+		data, _ := ioutil.ReadAll(resp.Body)
+		t.Fatalf("%s %s: want %d, got %d: %s", method, url, want, resp.StatusCode, data)
+	}
+	if v != nil {
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			t.Fatalf("%s %s: %v", method, url, err)
+		}
+	}
+}
+
+func TestAdminAPI(t *testing.T) {
+	dir, err := ioutil.TempDir("", "server")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	srv := newServer(t, dir)
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	created := server.Status{}
+	call(t, "POST", ts.URL+"/matches", `{
+		"roster": {"players": [{"name": "p1", "kind": "immobile"}, {"name": "p2", "kind": "immobile"}]},
+		"rules": {"TurnDuration": 1000000, "DefaultMaxBomb": 1, "DefaultBombRadius": 1}
+	}`, http.StatusCreated, &created)
+	match := ts.URL + "/matches/" + created.ID
+
+	call(t, "POST", match+"/pause", "", http.StatusOK, nil)
+	paused := server.Status{}
+	call(t, "GET", match, "", http.StatusOK, &paused)
+	time.Sleep(10 * time.Millisecond)
+	later := server.Status{}
+	call(t, "GET", match, "", http.StatusOK, &later)
+	if paused.State != server.Paused || later.Turn != paused.Turn {
+		t.Fatalf("want the paused match to stay at turn %d, got %+v", paused.Turn, later)
+	}
+	stepped := server.Status{}
+	call(t, "POST", match+"/step", "", http.StatusOK, &stepped)
+	if stepped.Turn != paused.Turn+1 || stepped.State != server.Paused {
+		t.Fatalf("want a single turn played from %d, got %+v", paused.Turn, stepped)
+	}
+	call(t, "POST", match+"/speed?turn=2ms", "", http.StatusOK, nil)
+	call(t, "POST", match+"/speed?turn=fast", "", http.StatusBadRequest, nil)
+
+	snap := engine.Snapshot{}
+	call(t, "GET", match+"/state", "", http.StatusOK, &snap)
+	if len(snap.Players) != 2 {
+		t.Fatalf("want both players in the state, got %+v", snap)
+	}
+	if p := snap.Players[0]; p.MaxBomb != 1 || p.TurnDuration != 2*time.Millisecond {
+		t.Errorf("want the rules of the spec, sped up, got %+v", p)
+	}
+
+	view := [][]*cell.Exported{}
+	call(t, "GET", match+"/board?player=p1", "", http.StatusOK, &view)
+	if len(view) != len(snap.Board) || view[1][1].Name != "p1" {
+		t.Errorf("want p1 to see the board, with themselves at (1, 1), got %+v", view)
+	}
+	call(t, "GET", match+"/board?player=nobody", "", http.StatusNotFound, nil)
+	report := []stats.Player{}
+	call(t, "GET", match+"/stats", "", http.StatusOK, &report)
+	if len(report) != 2 || !report[0].Alive {
+		t.Errorf("want the stats of both players, got %+v", report)
+	}
+
+	call(t, "POST", match+"/kick/nobody", "", http.StatusNotFound, nil)
+	call(t, "POST", match+"/kick/p2", "", http.StatusOK, nil)
+	call(t, "POST", match+"/resume", "", http.StatusOK, nil)
+	call(t, "POST", match+"/step", "", http.StatusConflict, nil)
+	for {
+		status := server.Status{}
+		call(t, "GET", match, "", http.StatusOK, &status)
+		if status.State == server.Finished {
+			if status.Result.Winner != "p1" {
+				t.Errorf("want p1 to win once p2 is kicked, got %+v", status.Result)
+			}
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	call(t, "POST", match+"/pause", "", http.StatusConflict, nil)
+
+	replay := server.Record{}
+	call(t, "GET", match+"/replay", "", http.StatusOK, &replay)
+	if replay.ID != created.ID || replay.Result == nil {
+		t.Errorf("want the record of the finished match, got %+v", replay)
+	}
+
+	aborted := server.Status{}
+	call(t, "POST", ts.URL+"/matches", `{"seats": [{"name": "p1", "kind": "immobile"}, {"name": "p2", "kind": "immobile"}]}`,
+		http.StatusCreated, &aborted)
+	call(t, "POST", ts.URL+"/matches/"+aborted.ID+"/abort", "", http.StatusOK, nil)
+	for aborted.State != server.Aborted {
+		time.Sleep(time.Millisecond)
+		call(t, "GET", ts.URL+"/matches/"+aborted.ID, "", http.StatusOK, &aborted)
+	}
+
+	list := []server.Status{}
+	call(t, "GET", ts.URL+"/matches", "", http.StatusOK, &list)
+	if len(list) != 2 || list[0].ID != created.ID {
+		t.Errorf("want both matches listed, oldest first, got %+v", list)
+	}
+	call(t, "GET", ts.URL+"/matches/nope", "", http.StatusNotFound, nil)
+}
+
+func TestAdminAPIRefusesCommands(t *testing.T) {
+	dir, err := ioutil.TempDir("", "server")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ts := httptest.NewServer(newServer(t, dir).Handler())
+	defer ts.Close()
+
+	touched := filepath.Join(dir, "touched")
+	spec := `{"seats": [
+		{"name": "p1", "kind": "process", "command": ["touch", "` + touched + `"]},
+		{"name": "p2", "kind": "immobile"}
+	]}`
+	call(t, "POST", ts.URL+"/matches", spec, http.StatusBadRequest, nil)
+	call(t, "POST", ts.URL+"/matches", `{"seats": [{"name": "p1", "kind": "external"}, {"name": "p2", "kind": "immobile"}]}`,
+		http.StatusBadRequest, nil)
+
+	resp, err := http.Post(ts.URL+"/matches", "text/plain", strings.NewReader(`{"seats": [{"name": "p1", "kind": "immobile"}, {"name": "p2", "kind": "immobile"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnsupportedMediaType {
+		t.Errorf("want matches refused from text/plain, got %s", resp.Status)
+	}
+	if _, err := os.Stat(touched); !os.IsNotExist(err) {
+		t.Errorf("want the command never run, got %v", err)
+	}
+}
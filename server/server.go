@@ -0,0 +1,797 @@
+// Package server hosts many matches at once, each with its own game, board
+// and players, played by a goroutine of its own.
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/aybabtme/bomberman/cell"
+	"github.com/aybabtme/bomberman/config"
+	"github.com/aybabtme/bomberman/engine"
+	"github.com/aybabtme/bomberman/logger"
+	"github.com/aybabtme/bomberman/match"
+	"github.com/aybabtme/bomberman/player"
+	"github.com/aybabtme/bomberman/rating"
+	"github.com/aybabtme/bomberman/stats"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrFull is returned when creating a match while MaxMatches run.
+	ErrFull = errors.New("too many matches running")
+	// ErrClosed is returned when creating a match while shutting down.
+	ErrClosed = errors.New("server is shutting down")
+	// ErrNotFound is returned for matches the server doesn't know.
+	ErrNotFound = errors.New("no such match")
+	// ErrNoPlayer is returned for players not seated in a match.
+	ErrNoPlayer = errors.New("no such player")
+	// ErrNotRunning is returned when acting on a match that's over.
+	ErrNotRunning = errors.New("match isn't running")
+	// ErrNotPaused is returned when stepping through a match not paused.
+	ErrNotPaused = errors.New("match isn't paused")
+)
+
+// Config is how the server hosts matches. Limits left to zero are unlimited.
+type Config struct {
+	// Match is what every match is played under.
+	Match match.Config `json:"match"`
+	// MaxMatches running at once.
+	MaxMatches int `json:"max_matches"`
+	// MaxPlayers seated in a match.
+	MaxPlayers int `json:"max_players"`
+	// MaxTurns a match lasts, also given to matches not asking for less.
+	MaxTurns int `json:"max_turns"`
+	// Commands are those process players may run, as given in their seat.
+	// Process players running anything else are refused.
+	Commands [][]string `json:"commands,omitempty"`
+	// Ratings, if set, rates the players of every finished match.
+	Ratings *rating.Store `json:"-"`
+	// MatchLog, when set, creates the logger of every match, and what
+	// closes it.
+	MatchLog func(id string) (*logger.Logger, func()) `json:"-"`
+}
+
+// Spec asks for a match.
+type Spec struct {
+	// Seats lists the players from the first spawn on. They're taken from
+	// the roster when missing.
+	Seats    []config.Entry `json:"seats"`
+	Seed     int64          `json:"seed"`
+	MaxTurns int            `json:"max_turns,omitempty"`
+	// Rules replace those of the server.
+	Rules *engine.Rules `json:"rules,omitempty"`
+	// Roster gives the players, friendly fire and vision of the match, like
+	// the roster of a local game. Its power-ups are ignored.
+	Roster *config.Roster `json:"roster,omitempty"`
+	// Fast matches are played as fast as possible, instead of a turn every
+	// turn duration.
+	Fast bool `json:"fast,omitempty"`
+}
+
+// State is where a match is at.
+type State string
+
+const (
+	Running  State = "running"
+	Paused   State = "paused"
+	Finished State = "finished"
+	// Aborted matches were stopped by an admin, and have no result.
+	Aborted State = "aborted"
+	// Saved matches were stopped by a shutdown, and resume on the next start.
+	Saved State = "saved"
+	// Failed matches crashed.
+	Failed State = "failed"
+)
+
+// Status is how a hosted match is doing.
+type Status struct {
+	ID      string        `json:"id"`
+	State   State         `json:"state"`
+	Spec    Spec          `json:"spec"`
+	Turn    int           `json:"turn"`
+	Started time.Time     `json:"started"`
+	Result  *match.Result `json:"result,omitempty"`
+	Error   string        `json:"error,omitempty"`
+}
+
+// Record is what's written of a match: enough to resume it, or to replay it
+// once it's over.
+type Record struct {
+	ID      string            `json:"id"`
+	Spec    Spec              `json:"spec"`
+	Match   match.Config      `json:"match"`
+	Started time.Time         `json:"started"`
+	Moves   [][][]player.Move `json:"moves"`
+	// Forfeits are players taken out between the moves.
+	Forfeits []match.Forfeit `json:"forfeits,omitempty"`
+	Result   *match.Result   `json:"result,omitempty"`
+}
+
+// Server hosts matches. Matches over, or saved, are written in its directory.
+type Server struct {
+	cfg Config
+	dir string
+	log *logger.Logger
+
+	mu      sync.Mutex
+	matches map[string]*hosted
+	order   []string
+	running int
+	closing bool
+	seq     int
+	wg      sync.WaitGroup
+}
+
+type hosted struct {
+	cfg     match.Config
+	stop    chan struct{}
+	once    sync.Once
+	wake    chan struct{}
+	stepOne chan chan struct{}
+	speed   chan time.Duration
+	// done is closed once the goroutine of the match returns.
+	done     chan struct{}
+	closeLog func()
+
+	// mu guards the match and its status.
+	mu     sync.Mutex
+	match  *match.Match
+	status Status
+	// stopAs is what the match becomes once stopped: saved or aborted.
+	stopAs State
+}
+
+// New creates a server writing its matches in dir.
+func New(cfg Config, dir string, log *logger.Logger) (*Server, error) {
+	for _, sub := range []string{"saved", "finished"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0755); err != nil {
+			return nil, err
+		}
+	}
+	return &Server{
+		cfg:     cfg,
+		dir:     dir,
+		log:     log,
+		matches: make(map[string]*hosted),
+	}, nil
+}
+
+// Create starts a match.
+func (s *Server) Create(spec Spec) (Status, error) {
+	if err := s.validate(&spec); err != nil {
+		return Status{}, err
+	}
+	s.mu.Lock()
+	switch {
+	case s.closing:
+		s.mu.Unlock()
+		return Status{}, ErrClosed
+	case s.cfg.MaxMatches > 0 && s.running >= s.cfg.MaxMatches:
+		s.mu.Unlock()
+		return Status{}, ErrFull
+	}
+	s.seq++
+	id := fmt.Sprintf("%s-%d", time.Now().Format("20060102-150405"), s.seq)
+	s.reserve()
+	s.mu.Unlock()
+
+	h, err := s.launch(&Record{ID: id, Spec: spec, Match: s.cfg.Match, Started: time.Now()})
+	if err != nil {
+		return Status{}, err
+	}
+	return h.snapshot(), nil
+}
+
+func (s *Server) validate(spec *Spec) error {
+	if len(spec.Seats) == 0 && spec.Roster != nil {
+		spec.Seats = spec.Roster.Players
+	}
+	if len(spec.Seats) < 2 {
+		return fmt.Errorf("a match needs at least 2 players, got %d", len(spec.Seats))
+	}
+	if s.cfg.MaxPlayers > 0 && len(spec.Seats) > s.cfg.MaxPlayers {
+		return fmt.Errorf("matches seat at most %d players, got %d", s.cfg.MaxPlayers, len(spec.Seats))
+	}
+	names := make(map[string]bool)
+	for i, e := range spec.Seats {
+		if e.Name == "" || names[e.Name] {
+			return fmt.Errorf("seat %d: missing or duplicate name %q", i, e.Name)
+		}
+		names[e.Name] = true
+		if !match.Playable(e.Kind) || e.Kind == match.External {
+			return fmt.Errorf("seat %q: kind %q can't be hosted", e.Name, e.Kind)
+		}
+		if e.Kind == "process" && !s.allowed(e.Command) {
+			return fmt.Errorf("seat %q: command %q isn't allowed", e.Name, e.Command)
+		}
+	}
+	if err := config.CheckTeams(spec.Seats); err != nil {
+		return err
+	}
+	if max := s.cfg.MaxTurns; max > 0 && (spec.MaxTurns <= 0 || spec.MaxTurns > max) {
+		spec.MaxTurns = max
+	}
+	return nil
+}
+
+// allowed tells if the config lets process players run a command.
+func (s *Server) allowed(command []string) bool {
+	for _, c := range s.cfg.Commands {
+		if len(c) != len(command) {
+			continue
+		}
+		same := true
+		for i := range c {
+			same = same && c[i] == command[i]
+		}
+		if same {
+			return true
+		}
+	}
+	return false
+}
+
+// reserve counts a match as running. It must be called with mu held.
+func (s *Server) reserve() {
+	s.running++
+	s.wg.Add(1)
+}
+
+func (s *Server) release() {
+	s.mu.Lock()
+	s.running--
+	s.mu.Unlock()
+	s.wg.Done()
+}
+
+// launch starts a match a slot was reserved for. The slot is released if the
+// match can't start, even if setting it up panics, so Shutdown doesn't wait
+// for it.
+func (s *Server) launch(rec *Record) (h *hosted, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("starting match %s: %v", rec.ID, r)
+		}
+		if err != nil {
+			s.release()
+		}
+	}()
+	return s.start(rec)
+}
+
+// start sets a match up, plays the moves it already made, and starts its
+// goroutine.
+func (s *Server) start(rec *Record) (*hosted, error) {
+	cfg := rec.Match
+	cfg.Record = true
+	cfg.MaxTurns = rec.Spec.MaxTurns
+	if rec.Spec.Rules != nil {
+		cfg.Rules = *rec.Spec.Rules
+	}
+	if r := rec.Spec.Roster; r != nil {
+		cfg.FriendlyFire = r.FriendlyFire
+		cfg.Vision.Radius, cfg.Vision.LineOfSight = r.VisionRadius, r.LineOfSight
+	}
+	// Neither the map nor where crashes go are saved with a match.
+	cfg.Map = s.cfg.Match.Map
+	cfg.CrashDir = s.cfg.Match.CrashDir
+
+	log, closeLog := s.log.With("match", rec.ID), func() {}
+	if s.cfg.MatchLog != nil {
+		log, closeLog = s.cfg.MatchLog(rec.ID)
+	}
+	m, err := match.New(cfg, rec.Spec.Seats, rec.Spec.Seed, log)
+	if err != nil {
+		closeLog()
+		return nil, err
+	}
+	m.Restore(rec.Moves, rec.Forfeits)
+
+	h := &hosted{
+		cfg:      cfg,
+		stop:     make(chan struct{}),
+		wake:     make(chan struct{}, 1),
+		stepOne:  make(chan chan struct{}),
+		speed:    make(chan time.Duration),
+		done:     make(chan struct{}),
+		closeLog: closeLog,
+		match:    m,
+		status: Status{
+			ID:      rec.ID,
+			State:   Running,
+			Spec:    rec.Spec,
+			Turn:    m.Steps(),
+			Started: rec.Started,
+		},
+	}
+	s.mu.Lock()
+	if _, ok := s.matches[rec.ID]; !ok {
+		s.order = append(s.order, rec.ID)
+	}
+	s.matches[rec.ID] = h
+	s.mu.Unlock()
+
+	log.Infof("Hosting match of %d players, from turn %d.", len(rec.Spec.Seats), m.Steps())
+	go s.run(h)
+	return h, nil
+}
+
+// run plays a match until it's over, or until the server stops it.
+func (s *Server) run(h *hosted) {
+	defer close(h.done)
+	defer s.release()
+	defer h.closeLog()
+	defer func() {
+		if r := recover(); r != nil {
+			h.mu.Lock()
+			if h.match != nil {
+				h.match.Close()
+				h.match = nil
+			}
+			h.status.State, h.status.Error = Failed, fmt.Sprintf("panic: %v", r)
+			h.mu.Unlock()
+			s.log.With("match", h.status.ID).Errorf("Match crashed: %v", r)
+		}
+	}()
+
+	// Fast matches never wait: receiving from a closed channel never blocks.
+	ready := make(chan time.Time)
+	close(ready)
+	var ticker *time.Ticker
+	var tick <-chan time.Time = ready
+	if !h.status.Spec.Fast && h.cfg.Rules.TurnDuration > 0 {
+		ticker = time.NewTicker(h.cfg.Rules.TurnDuration)
+		tick = ticker.C
+	}
+	defer func() {
+		if ticker != nil {
+			ticker.Stop()
+		}
+	}()
+	for {
+		// Paused matches only play the turns they're stepped through.
+		turns := tick
+		if h.snapshot().State == Paused {
+			turns = nil
+		}
+		var stepped chan struct{}
+		select {
+		case <-h.stop:
+			s.stopped(h)
+			return
+		case <-h.wake:
+			continue
+		case d := <-h.speed:
+			if ticker == nil {
+				ticker = time.NewTicker(d)
+			} else {
+				ticker.Reset(d)
+			}
+			tick = ticker.C
+			continue
+		case stepped = <-h.stepOne:
+		case <-turns:
+		}
+		res, over := h.step(stepped != nil)
+		if over {
+			s.finish(h, res)
+		}
+		if stepped != nil {
+			close(stepped)
+		}
+		if over {
+			return
+		}
+	}
+}
+
+// step plays a turn, unless the match was paused meanwhile and the turn
+// isn't forced.
+func (h *hosted) step(force bool) (*match.Result, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.status.State != Running && !force {
+		return nil, false
+	}
+	h.match.Step()
+	h.status.Turn = h.match.Steps()
+	return h.match.Over()
+}
+
+// halt asks the goroutine of a match to stop, and the match to become
+// saved or aborted.
+func (h *hosted) halt(as State) {
+	h.once.Do(func() {
+		h.mu.Lock()
+		h.stopAs = as
+		h.mu.Unlock()
+		close(h.stop)
+	})
+}
+
+func (s *Server) stopped(h *hosted) {
+	h.mu.Lock()
+	as := h.stopAs
+	h.mu.Unlock()
+	if as == Aborted {
+		s.abort(h)
+	} else {
+		s.save(h)
+	}
+}
+
+// finish writes a match over, and forgets its board.
+func (s *Server) finish(h *hosted, res *match.Result) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.match.Close()
+	rec := h.record()
+	res.Moves, res.Forfeits = nil, nil
+	rec.Result = res
+	h.status.State, h.status.Result = Finished, res
+	h.match = nil
+
+	if err := s.write("finished", rec); err != nil {
+		s.log.With("match", rec.ID).Errorf("Writing finished match: %v", err)
+	}
+	os.Remove(filepath.Join(s.dir, "saved", rec.ID+".json"))
+	if err := s.rate(rec); err != nil {
+		s.log.With("match", rec.ID).Errorf("Rating match: %v", err)
+	}
+}
+
+// rate rates the players of a finished match, if the server keeps ratings.
+func (s *Server) rate(rec *Record) error {
+	if s.cfg.Ratings == nil {
+		return nil
+	}
+	names := make([]string, len(rec.Spec.Seats))
+	for i, e := range rec.Spec.Seats {
+		names[i] = e.Name
+	}
+	_, err := s.cfg.Ratings.Record(rating.Match{
+		ID:      "serve/" + rec.ID,
+		Players: names,
+		Ranks:   rec.Result.Ranks(names),
+	})
+	return err
+}
+
+// abort writes a match stopped by an admin, without a result.
+func (s *Server) abort(h *hosted) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.match.Close()
+	rec := h.record()
+	h.status.State = Aborted
+	h.match = nil
+
+	if err := s.write("finished", rec); err != nil {
+		s.log.With("match", rec.ID).Errorf("Writing aborted match: %v", err)
+	}
+	os.Remove(filepath.Join(s.dir, "saved", rec.ID+".json"))
+}
+
+// save writes a match stopped before it's over, to resume it later.
+func (s *Server) save(h *hosted) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.match.Close()
+	rec := h.record()
+	h.status.State = Saved
+	h.match = nil
+
+	if err := s.write("saved", rec); err != nil {
+		s.log.With("match", rec.ID).Errorf("Saving match: %v", err)
+		h.status.State, h.status.Error = Failed, err.Error()
+	}
+}
+
+// record is what's written of a match. It must be called with mu held.
+func (h *hosted) record() *Record {
+	return &Record{
+		ID:       h.status.ID,
+		Spec:     h.status.Spec,
+		Match:    h.cfg,
+		Started:  h.status.Started,
+		Moves:    append([][][]player.Move(nil), h.match.Moves()...),
+		Forfeits: append([]match.Forfeit(nil), h.match.Forfeits()...),
+	}
+}
+
+func (h *hosted) snapshot() Status {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.status
+}
+
+// write replaces the record of a match at once, so an interruption never
+// leaves half of it behind.
+func (s *Server) write(sub string, rec *Record) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	filename := filepath.Join(s.dir, sub, rec.ID+".json")
+	if err := ioutil.WriteFile(filename+".tmp", data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(filename+".tmp", filename)
+}
+
+// Resume starts again the matches saved by the last shutdown. They don't
+// count against MaxMatches.
+func (s *Server) Resume() (int, error) {
+	files, err := filepath.Glob(filepath.Join(s.dir, "saved", "*.json"))
+	if err != nil {
+		return 0, err
+	}
+	sort.Strings(files)
+	resumed := 0
+	for _, filename := range files {
+		rec := &Record{}
+		data, err := ioutil.ReadFile(filename)
+		if err == nil {
+			err = json.Unmarshal(data, rec)
+		}
+		if err != nil {
+			return resumed, fmt.Errorf("%s: %v", filename, err)
+		}
+		if rec.ID == "" {
+			rec.ID = strings.TrimSuffix(filepath.Base(filename), ".json")
+		}
+
+		s.mu.Lock()
+		if s.closing {
+			s.mu.Unlock()
+			return resumed, ErrClosed
+		}
+		s.reserve()
+		s.mu.Unlock()
+		if _, err := s.launch(rec); err != nil {
+			return resumed, fmt.Errorf("resuming match %s: %v", rec.ID, err)
+		}
+		resumed++
+	}
+	return resumed, nil
+}
+
+// List tells how every match is doing, oldest first.
+func (s *Server) List() []Status {
+	s.mu.Lock()
+	hosted := make([]*hosted, len(s.order))
+	for i, id := range s.order {
+		hosted[i] = s.matches[id]
+	}
+	s.mu.Unlock()
+
+	list := make([]Status, len(hosted))
+	for i, h := range hosted {
+		list[i] = h.snapshot()
+	}
+	return list
+}
+
+// Get tells how a match is doing.
+func (s *Server) Get(id string) (Status, bool) {
+	s.mu.Lock()
+	h, ok := s.matches[id]
+	s.mu.Unlock()
+	if !ok {
+		return Status{}, false
+	}
+	return h.snapshot(), true
+}
+
+// Shutdown stops creating matches, and lets running ones finish until ctx is
+// done. The matches still running then are saved, to resume on the next
+// start.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	s.closing = true
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+	}
+
+	s.mu.Lock()
+	for _, h := range s.matches {
+		h.halt(Saved)
+	}
+	s.mu.Unlock()
+	<-done
+	return ctx.Err()
+}
+
+// lookup finds a match.
+func (s *Server) lookup(id string) (*hosted, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, ok := s.matches[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return h, nil
+}
+
+// playing calls do with a match that's still being played.
+func (s *Server) playing(id string, do func(h *hosted) error) error {
+	h, err := s.lookup(id)
+	if err != nil {
+		return err
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.match == nil {
+		return ErrNotRunning
+	}
+	return do(h)
+}
+
+// Pause stops a match from playing turns until it's continued.
+func (s *Server) Pause(id string) error {
+	return s.playing(id, func(h *hosted) error {
+		h.status.State = Paused
+		return nil
+	})
+}
+
+// Continue plays a paused match again.
+func (s *Server) Continue(id string) error {
+	return s.playing(id, func(h *hosted) error {
+		h.status.State = Running
+		select {
+		case h.wake <- struct{}{}:
+		default:
+		}
+		return nil
+	})
+}
+
+// Step plays a single turn of a paused match.
+func (s *Server) Step(id string) error {
+	h, err := s.lookup(id)
+	if err != nil {
+		return err
+	}
+	if h.snapshot().State != Paused {
+		return ErrNotPaused
+	}
+	stepped := make(chan struct{})
+	select {
+	case h.stepOne <- stepped:
+		<-stepped
+		return nil
+	case <-h.done:
+		return ErrNotRunning
+	}
+}
+
+// Speed changes how long the turns of a match last. Fast matches then play
+// at that speed too.
+func (s *Server) Speed(id string, d time.Duration) error {
+	if d <= 0 {
+		return fmt.Errorf("turns must last more than %v", d)
+	}
+	err := s.playing(id, func(h *hosted) error {
+		h.cfg.Rules.TurnDuration = d
+		h.status.Spec.Fast = false
+		h.match.Engine.SetTurnDuration(d)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	h, _ := s.lookup(id)
+	select {
+	case h.speed <- d:
+		return nil
+	case <-h.done:
+		return ErrNotRunning
+	}
+}
+
+// Abort stops a match for good. It's written without a result.
+func (s *Server) Abort(id string) error {
+	h, err := s.lookup(id)
+	if err != nil {
+		return err
+	}
+	if st := h.snapshot().State; st != Running && st != Paused {
+		return ErrNotRunning
+	}
+	h.halt(Aborted)
+	return nil
+}
+
+// Kick takes a player out of a match, as if they forfeited.
+func (s *Server) Kick(id, name string) error {
+	return s.playing(id, func(h *hosted) error {
+		for _, pState := range h.match.Players {
+			if pState.Name == name {
+				h.match.Forfeit(pState, errors.New("kicked"))
+				return nil
+			}
+		}
+		return ErrNoPlayer
+	})
+}
+
+// Snapshot is the state of a match being played: its turn, board, players
+// and bombs.
+func (s *Server) Snapshot(id string) (*engine.Snapshot, error) {
+	var snap *engine.Snapshot
+	err := s.playing(id, func(h *hosted) error {
+		snap = h.match.Engine.Snapshot()
+		return nil
+	})
+	return snap, err
+}
+
+// View is the board of a match as a player sees it, with their allies.
+func (s *Server) View(id, name string) ([][]*cell.Exported, error) {
+	var view [][]*cell.Exported
+	err := s.playing(id, func(h *hosted) error {
+		for _, pState := range h.match.Players {
+			if pState.Name == name {
+				view = h.match.Engine.View(pState)
+				return nil
+			}
+		}
+		return ErrNoPlayer
+	})
+	return view, err
+}
+
+// Stats tells what every player of a match being played did so far.
+func (s *Server) Stats(id string) ([]stats.Player, error) {
+	var report []stats.Player
+	err := s.playing(id, func(h *hosted) error {
+		report = h.match.Stats.Report()
+		return nil
+	})
+	return report, err
+}
+
+// Replay is the record of a match: its moves so far while it's played, and
+// its result once it's over.
+func (s *Server) Replay(id string) (*Record, error) {
+	h, err := s.lookup(id)
+	if err != nil {
+		return nil, err
+	}
+	h.mu.Lock()
+	if h.match != nil {
+		defer h.mu.Unlock()
+		return h.record(), nil
+	}
+	h.mu.Unlock()
+
+	for _, sub := range []string{"finished", "saved"} {
+		data, err := ioutil.ReadFile(filepath.Join(s.dir, sub, id+".json"))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		rec := &Record{}
+		return rec, json.Unmarshal(data, rec)
+	}
+	return nil, ErrNotFound
+}
@@ -0,0 +1,197 @@
+package server_test
+
+import (
+	"context"
+	"github.com/aybabtme/bomberman/board"
+	"github.com/aybabtme/bomberman/config"
+	"github.com/aybabtme/bomberman/engine"
+	"github.com/aybabtme/bomberman/logger"
+	"github.com/aybabtme/bomberman/match"
+	"github.com/aybabtme/bomberman/rating"
+	"github.com/aybabtme/bomberman/server"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newServer(t *testing.T, dir string) *server.Server {
+	return newServerWith(t, dir, func(*server.Config) {})
+}
+
+// newServerWith creates a server with changes to the config of the tests.
+func newServerWith(t *testing.T, dir string, change func(*server.Config)) *server.Server {
+	m, err := board.LoadMap(strings.NewReader("#######\n#1...2#\n#######\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules := engine.DefaultRules
+	rules.TurnDuration = 10 * time.Millisecond
+	cfg := server.Config{
+		Match:      match.Config{Map: m, Rules: rules},
+		MaxMatches: 2,
+		MaxTurns:   50,
+	}
+	change(&cfg)
+	srv, err := server.New(cfg, dir, logger.NewWriter("", ioutil.Discard, logger.Error))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return srv
+}
+
+var seats = []config.Entry{
+	{Name: "p1", Kind: "wandering"},
+	{Name: "p2", Kind: "wandering"},
+}
+
+func TestServerLimitsAndShutdown(t *testing.T) {
+	dir, err := ioutil.TempDir("", "server")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	srv := newServer(t, dir)
+	fast, err := srv.Create(server.Spec{Seats: seats, Seed: 1, Fast: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	slow, err := srv.Create(server.Spec{Seats: seats, Seed: 2, MaxTurns: 1000000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if slow.Spec.MaxTurns != 50 {
+		t.Errorf("want max turns capped to 50, got %d", slow.Spec.MaxTurns)
+	}
+
+	for {
+		if s, _ := srv.Get(fast.ID); s.State == server.Finished {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if s, _ := srv.Get(fast.ID); s.Result == nil || s.Turn != 50 {
+		t.Errorf("want the fast match over after 50 turns, got %+v", s)
+	}
+
+	// Kicked while paused, p2 must stay out once resumed.
+	if err := srv.Pause(slow.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.Kick(slow.ID, "p2"); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	srv.Shutdown(ctx)
+	if _, err := srv.Create(server.Spec{Seats: seats}); err != server.ErrClosed {
+		t.Errorf("want no match created while shutting down, got %v", err)
+	}
+	saved, _ := srv.Get(slow.ID)
+	if saved.State != server.Saved {
+		t.Fatalf("want the slow match saved, got %+v", saved)
+	}
+
+	again := newServer(t, dir)
+	if n, err := again.Resume(); err != nil || n != 1 {
+		t.Fatalf("want the saved match resumed, got %d: %v", n, err)
+	}
+	resumed, ok := again.Get(slow.ID)
+	if !ok || resumed.Turn < saved.Turn {
+		t.Errorf("want the match resumed from turn %d, got %+v", saved.Turn, resumed)
+	}
+	again.Shutdown(context.Background())
+	if s, _ := again.Get(slow.ID); s.State != server.Finished || s.Result.Winner != "p1" || s.Turn != saved.Turn+1 {
+		t.Errorf("want the resumed match won by p1 on the next turn, p2 being kicked, got %+v", s)
+	}
+}
+
+func TestServerRatesFinishedMatches(t *testing.T) {
+	dir, err := ioutil.TempDir("", "server")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sys, _ := rating.NewSystem("elo")
+	ratings, err := rating.Open(filepath.Join(dir, "ratings.jsonl"), sys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ratings.Close()
+	srv := newServerWith(t, dir, func(cfg *server.Config) { cfg.Ratings = ratings })
+	if _, err := srv.Create(server.Spec{Seats: seats, Seed: 1, Fast: true}); err != nil {
+		t.Fatal(err)
+	}
+	srv.Shutdown(context.Background())
+
+	if played := ratings.History("p2"); len(played) != 1 || played[0].Players[0] != "p1" {
+		t.Errorf("want the match rated, got %+v", played)
+	}
+}
+
+func TestServerRefusesPlayersNamedLikeTeams(t *testing.T) {
+	dir, err := ioutil.TempDir("", "server")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	srv := newServer(t, dir)
+	defer srv.Shutdown(context.Background())
+	_, err = srv.Create(server.Spec{Seats: []config.Entry{
+		{Name: "red", Kind: "wandering"},
+		{Name: "p2", Kind: "wandering", Team: "red"},
+	}})
+	if err == nil || !strings.Contains(err.Error(), "named like a team") {
+		t.Errorf("want a player named like a team refused, got %v", err)
+	}
+}
+
+func TestServerFull(t *testing.T) {
+	dir, err := ioutil.TempDir("", "server")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	srv := newServer(t, dir)
+	defer func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		srv.Shutdown(ctx)
+	}()
+	for i := 0; i < 2; i++ {
+		if _, err := srv.Create(server.Spec{Seats: seats}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := srv.Create(server.Spec{Seats: seats}); err != server.ErrFull {
+		t.Errorf("want the server full, got %v", err)
+	}
+}
+
+func TestServerReleasesMatchesThatPanic(t *testing.T) {
+	dir, err := ioutil.TempDir("", "server")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	srv := newServerWith(t, dir, func(cfg *server.Config) {
+		cfg.MatchLog = func(string) (*logger.Logger, func()) { panic("no log for you") }
+	})
+	if _, err := srv.Create(server.Spec{Seats: seats}); err == nil {
+		t.Fatal("want the match refused")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		t.Errorf("want a clean shutdown, got %v", err)
+	}
+}
@@ -0,0 +1,303 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/aybabtme/bomberman/cell"
+	"github.com/aybabtme/bomberman/config"
+	"github.com/aybabtme/bomberman/engine"
+	"github.com/aybabtme/bomberman/keys"
+	"github.com/aybabtme/bomberman/objects"
+	"github.com/aybabtme/bomberman/player"
+	"github.com/aybabtme/bomberman/server"
+	"github.com/aybabtme/bomberman/stats"
+	"github.com/nsf/termbox-go"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// view is what spectators see of a match: the board, through the eyes of the
+// player they follow if any, and how every player is doing.
+type view struct {
+	Board   [][]*cell.Exported
+	Players []player.State
+	Stats   []stats.Player
+	Follow  string
+}
+
+// draw draws the board, and a line for every player under the status line.
+func (v *view) draw() {
+	bg := make(map[string]termbox.Attribute)
+	teamColor := make(map[string]termbox.Attribute)
+	for _, p := range v.Players {
+		if _, ok := teamColor[p.Team]; p.Team != "" && !ok {
+			teamColor[p.Team] = objects.TeamColors[len(teamColor)%len(objects.TeamColors)]
+		}
+		bg[p.Name] = teamColor[p.Team]
+	}
+	for x, column := range v.Board {
+		for y, c := range column {
+			if len(c.Layers) > 0 {
+				objects.ForLayer(c.Layers[0], bg).Draw(x, y)
+			}
+		}
+	}
+
+	byName := make(map[string]*stats.Player, len(v.Stats))
+	for i := range v.Stats {
+		byName[v.Stats[i].Name] = &v.Stats[i]
+	}
+	y := 1
+	if len(v.Board) > 0 {
+		y += len(v.Board[0])
+	}
+	for i, p := range v.Players {
+		drawText(0, y+i, fmt.Sprintf("%-*s", w, hudLine(p, byName[p.Name], p.Name == v.Follow)))
+	}
+	termbox.Flush()
+}
+
+// hudLine tells how a player is doing, and what they did if their stats are
+// known. The followed player is marked.
+func hudLine(p player.State, s *stats.Player, followed bool) string {
+	mark := " "
+	if followed {
+		mark = ">"
+	}
+	line := fmt.Sprintf("%s %-8s %s", mark, p.Name, stateLine(p, s))
+	if s != nil {
+		line += fmt.Sprintf("  kills %d  walked %d", s.Kills, s.Distance)
+	}
+	return line
+}
+
+// stateLine tells the power-ups of a living player, or how they died.
+func stateLine(p player.State, s *stats.Player) string {
+	if !p.Alive {
+		switch {
+		case s != nil && s.Forfeited:
+			return "forfeited"
+		case s != nil && s.KilledBy != "":
+			return "killed by " + s.KilledBy
+		}
+		return "dead"
+	}
+	line := fmt.Sprintf("bombs %d/%d  radius %d  speed %d  shield %d",
+		p.Bombs, p.MaxBomb, p.MaxRadius, p.Speed, p.Shield)
+	if p.CanKick {
+		line += "  kick"
+	}
+	if p.CanRemote {
+		line += "  remote"
+	}
+	if p.Pierce {
+		line += "  pierce"
+	}
+	if p.CursedTurns > 0 {
+		line += fmt.Sprintf("  cursed %d", p.CursedTurns)
+	}
+	return line
+}
+
+// nextFollow is the player to follow after the one followed, in order, and
+// nobody after the last one.
+func nextFollow(players []player.State, follow string) string {
+	if follow == "" {
+		if len(players) == 0 {
+			return ""
+		}
+		return players[0].Name
+	}
+	for i, p := range players {
+		if p.Name == follow && i+1 < len(players) {
+			return players[i+1].Name
+		}
+	}
+	return ""
+}
+
+//////////////
+// Local
+
+// watcher draws a local match for spectators, instead of the whole board.
+type watcher struct {
+	collector *stats.Collector
+	follow    string
+}
+
+// watching is set when the local terminal only watches the match.
+var watching *watcher
+
+func (wt *watcher) draw(eng *engine.Engine) {
+	snap := eng.Snapshot()
+	v := &view{Board: snap.Board, Players: snap.Players, Stats: wt.collector.Report(), Follow: wt.follow}
+	for pState := range eng.Game.Players {
+		if pState.Name == wt.follow {
+			v.Board = eng.View(pState)
+		}
+	}
+	v.draw()
+}
+
+// drawBoard draws the board of a local match, as spectators see it if the
+// terminal only watches.
+func drawBoard(eng *engine.Engine) {
+	if watching == nil {
+		eng.Board.Draw()
+		return
+	}
+	watching.draw(eng)
+}
+
+//////////////
+// Remote
+
+// runSpectate watches a match hosted by a server, until it's over.
+func runSpectate(args []string) {
+	flags := flag.NewFlagSet("spectate", flag.ExitOnError)
+	logOpts := logFlags(flags, "info")
+	addr := flags.String("server", "localhost:8080", "address of the admin API of the server")
+	id := flags.String("match", "", "ID of the match to watch")
+	follow := flags.String("follow", "", "player whose sight is shown, instead of the whole board")
+	every := flags.Duration("every", 250*time.Millisecond, "how often the match is fetched")
+	keysFile := flags.String("keys", "", "JSON file binding keys to the controls of the game")
+	flags.Parse(args)
+	logOpts.open()
+	if _, err := loadKeys(*keysFile); err != nil {
+		log.Fatalf("Loading keys: %v", err)
+	}
+	if *id == "" {
+		log.Fatalf("Spectating needs a match, given with -match.")
+	}
+
+	c := &hostedMatch{
+		base:   "http://" + *addr + "/matches/" + url.PathEscape(*id),
+		client: &http.Client{Timeout: 5 * time.Second},
+	}
+	status, err := c.status()
+	if err != nil {
+		log.Fatalf("Finding match: %v", err)
+	}
+
+	if err := termbox.Init(); err != nil {
+		panic(err)
+	}
+	defer termbox.Close()
+	w, h = termbox.Size()
+
+	evChan := make(chan termbox.Event)
+	go func() {
+		for {
+			evChan <- termbox.PollEvent()
+		}
+	}()
+
+	tick := time.NewTicker(*every)
+	defer tick.Stop()
+	for status.State == server.Running || status.State == server.Paused {
+		v, err := c.view(*follow)
+		termbox.Clear(termbox.ColorDefault, termbox.ColorDefault)
+		line, y := fmt.Sprintf("match %s, turn %d, %s. %s", status.ID, status.Turn, status.State,
+			hints(keyHint(keys.Follow, "follow"), keyHint(keys.Quit, "quit"))), 0
+		if err != nil {
+			line = fmt.Sprintf("match %s: %v", status.ID, err)
+		} else if v.draw(); len(v.Board) > 0 {
+			y = len(v.Board[0])
+		}
+		drawText(0, y, line)
+		termbox.Flush()
+
+		select {
+		case <-tick.C:
+		case ev := <-evChan:
+			switch {
+			case ev.Type == termbox.EventResize:
+				w, h = ev.Width, ev.Height
+			case ev.Type == termbox.EventError:
+				log.Errorf("Terminal failed: %v", ev.Err)
+				return
+			case ev.Type != termbox.EventKey:
+			case gameKeys[keys.Of(ev)] == keys.Quit:
+				return
+			case gameKeys[keys.Of(ev)] == keys.Follow && v != nil:
+				*follow = nextFollow(v.Players, *follow)
+			}
+		}
+		if next, err := c.status(); err == nil {
+			status = next
+		}
+	}
+
+	title := fmt.Sprintf("Match %s.", status.State)
+	var report []stats.Player
+	if res := status.Result; res != nil {
+		title, report = resultTitle(res.Winner), res.Stats
+	} else if status.Error != "" {
+		title = fmt.Sprintf("Match %s: %s", status.State, status.Error)
+	}
+	showResults(title, report, evChan)
+}
+
+// hostedMatch fetches a match from the admin API of a server.
+type hostedMatch struct {
+	base   string
+	client *http.Client
+}
+
+func (c *hostedMatch) status() (server.Status, error) {
+	status := server.Status{}
+	err := c.get("", &status)
+	return status, err
+}
+
+// view fetches what spectators see, following a player if one is given.
+func (c *hostedMatch) view(follow string) (*view, error) {
+	v := &view{Follow: follow}
+	snap := engine.Snapshot{}
+	if err := c.get("/state", &snap); err != nil {
+		return nil, err
+	}
+	v.Board, v.Players = snap.Board, snap.Players
+	if err := c.get("/stats", &v.Stats); err != nil {
+		return nil, err
+	}
+	if follow != "" {
+		if err := c.get("/board?player="+url.QueryEscape(follow), &v.Board); err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+func (c *hostedMatch) get(path string, v interface{}) error {
+	resp, err := c.client.Get(c.base + path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// checkSpectated makes sure nobody plays from the keyboard of a spectator, and
+// that the followed player is in the match.
+func checkSpectated(roster *config.Roster, follow string) error {
+	found := follow == ""
+	for _, e := range roster.Players {
+		if e.Kind == "local" {
+			return fmt.Errorf("player %q plays from the keyboard; give a roster without local players", e.Name)
+		}
+		found = found || e.Name == follow
+	}
+	if !found {
+		return fmt.Errorf("can't follow %q, who isn't playing", follow)
+	}
+	return nil
+}
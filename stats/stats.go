@@ -0,0 +1,168 @@
+// Package stats counts what every player did during a match, to help bot
+// authors spot strange behaviour.
+package stats
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/aybabtme/bomberman/engine"
+	"github.com/aybabtme/bomberman/event"
+	"io"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+)
+
+// Player is what a player did during a match.
+type Player struct {
+	Name           string         `json:"name"`
+	BombsPlaced    int            `json:"bombs_placed"`
+	RocksDestroyed int            `json:"rocks_destroyed"`
+	PowerUps       int            `json:"powerups"`
+	PowerUpsByKind map[string]int `json:"powerups_by_kind,omitempty"`
+	Kills          int            `json:"kills"`
+	TeamKills      int            `json:"team_kills"`
+	Suicides       int            `json:"suicides"`
+	// Distance walked, in cells.
+	Distance      int    `json:"distance"`
+	TurnsSurvived int    `json:"turns_survived"`
+	Alive         bool   `json:"alive"`
+	KilledBy      string `json:"killed_by,omitempty"`
+	// Forfeited players were taken out, like when they crashed.
+	Forfeited bool `json:"forfeited,omitempty"`
+}
+
+// Collector counts the events of an engine.
+type Collector struct {
+	eng     *engine.Engine
+	players map[string]*Player
+	died    map[string]int
+}
+
+// Attach starts counting the events of an engine.
+func Attach(eng *engine.Engine) *Collector {
+	c := &Collector{
+		eng:     eng,
+		players: make(map[string]*Player),
+		died:    make(map[string]int),
+	}
+	eng.Events.Subscribe(c.count)
+	return c
+}
+
+func (c *Collector) count(e event.Event) {
+	switch e := e.(type) {
+	case event.BombPlaced:
+		c.of(e.Owner).BombsPlaced++
+	case event.RockDestroyed:
+		c.of(e.Owner).RocksDestroyed++
+	case event.PowerUpPicked:
+		p := c.of(e.Player)
+		p.PowerUps++
+		if p.PowerUpsByKind == nil {
+			p.PowerUpsByKind = make(map[string]int)
+		}
+		p.PowerUpsByKind[e.PowerUp]++
+	case event.PlayerMoved:
+		c.of(e.Player).Distance++
+	case event.PlayerDied:
+		c.died[e.Player] = e.Turn
+		c.of(e.Player).KilledBy = e.Killer
+		switch {
+		case e.Killer == e.Player:
+			c.of(e.Killer).Suicides++
+		case e.TeamKill:
+			c.of(e.Killer).TeamKills++
+		default:
+			c.of(e.Killer).Kills++
+		}
+	case event.PlayerForfeited:
+		c.died[e.Player] = e.Turn
+		c.of(e.Player).Forfeited = true
+	}
+}
+
+func (c *Collector) of(name string) *Player {
+	p, ok := c.players[name]
+	if !ok {
+		p = &Player{Name: name}
+		c.players[name] = p
+	}
+	return p
+}
+
+// Report tells what every player did so far, sorted by name.
+func (c *Collector) Report() []Player {
+	report := []Player{}
+	for pState := range c.eng.Game.Players {
+		p := *c.of(pState.Name)
+		p.Alive = pState.Alive
+		p.TurnsSurvived = c.eng.Game.Turn()
+		if turn, ok := c.died[pState.Name]; ok {
+			p.TurnsSurvived = turn
+		}
+		report = append(report, p)
+	}
+	sort.Slice(report, func(i, j int) bool { return report[i].Name < report[j].Name })
+	return report
+}
+
+// WriteJSON writes a report as JSON.
+func WriteJSON(w io.Writer, report []Player) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
+
+var columns = []string{
+	"name", "bombs_placed", "rocks_destroyed", "powerups", "kills", "team_kills",
+	"suicides", "distance", "turns_survived", "alive", "killed_by",
+	"forfeited",
+}
+
+func (p Player) row() []string {
+	return []string{
+		p.Name,
+		strconv.Itoa(p.BombsPlaced),
+		strconv.Itoa(p.RocksDestroyed),
+		strconv.Itoa(p.PowerUps),
+		strconv.Itoa(p.Kills),
+		strconv.Itoa(p.TeamKills),
+		strconv.Itoa(p.Suicides),
+		strconv.Itoa(p.Distance),
+		strconv.Itoa(p.TurnsSurvived),
+		strconv.FormatBool(p.Alive),
+		p.KilledBy,
+		strconv.FormatBool(p.Forfeited),
+	}
+}
+
+// WriteCSV writes a report as CSV, with a header.
+func WriteCSV(w io.Writer, report []Player) error {
+	cw := csv.NewWriter(w)
+	cw.Write(columns)
+	for _, p := range report {
+		cw.Write(p.row())
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// Table formats a report for people.
+func Table(report []Player) string {
+	buf := bytes.NewBuffer(nil)
+	tw := tabwriter.NewWriter(buf, 0, 4, 1, ' ', 0)
+	fmt.Fprintln(tw, "player\tbombs\trocks\tpowerups\tkills\tsuicides\twalked\tturns\tkilled by")
+	for _, p := range report {
+		killedBy := p.KilledBy
+		if p.Forfeited {
+			killedBy = "(forfeited)"
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%d\t%d\t%d\t%s\n", p.Name, p.BombsPlaced,
+			p.RocksDestroyed, p.PowerUps, p.Kills, p.Suicides, p.Distance, p.TurnsSurvived, killedBy)
+	}
+	tw.Flush()
+	return buf.String()
+}
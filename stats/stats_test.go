@@ -0,0 +1,55 @@
+package stats_test
+
+import (
+	"bytes"
+	"github.com/aybabtme/bomberman/board"
+	"github.com/aybabtme/bomberman/config"
+	"github.com/aybabtme/bomberman/engine"
+	"github.com/aybabtme/bomberman/logger"
+	"github.com/aybabtme/bomberman/match"
+	"github.com/aybabtme/bomberman/player"
+	"github.com/aybabtme/bomberman/stats"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestCollectorCountsBlast(t *testing.T) {
+	m, err := board.LoadMap(strings.NewReader("#####\n#1.2#\n#####\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	seats := []config.Entry{
+		{Name: "p1", Kind: match.External},
+		{Name: "p2", Kind: match.External},
+	}
+	game, err := match.New(match.Config{Map: m, Rules: engine.DefaultRules}, seats,
+		1, logger.NewWriter("", ioutil.Discard, logger.Error))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p1 := game.Players[0]
+	game.Move(p1, player.PutBomb)
+	game.Step()
+	game.Move(p1, player.Right)
+	for i := 0; i < 20; i++ {
+		game.Step()
+	}
+
+	report := game.Stats.Report()
+	if got := report[0]; got.Name != "p1" || got.BombsPlaced != 1 || got.Kills != 1 ||
+		got.Suicides != 1 || got.Distance != 1 || got.KilledBy != "p1" || got.Alive {
+		t.Errorf("want p1 to have placed a bomb, walked a cell and killed both, got %+v", got)
+	}
+	if got := report[1]; got.KilledBy != "p1" || got.Alive {
+		t.Errorf("want p2 killed by p1, got %+v", got)
+	}
+
+	buf := bytes.NewBuffer(nil)
+	if err := stats.WriteCSV(buf, report); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(buf.String(), "\n"); lines != 3 {
+		t.Errorf("want a header and 2 rows, got %q", buf.String())
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/aybabtme/bomberman/engine"
+	"github.com/aybabtme/bomberman/match"
+	"github.com/aybabtme/bomberman/powerup"
+	"github.com/aybabtme/bomberman/tournament"
+	"os"
+	"runtime"
+)
+
+// runTournament plays a bot competition without a terminal and prints the
+// standings.
+func runTournament(args []string) {
+	flags := flag.NewFlagSet("tournament", flag.ExitOnError)
+	logOpts := logFlags(flags, "warn")
+	configFile := flags.String("config", "", "JSON file describing the tournament and its entries")
+	out := flags.String("out", "tournament", "directory to save matches and standings in; rerun to resume")
+	workers := flags.Int("workers", runtime.NumCPU(), "how many matches to play at once")
+	ratings := flags.String("ratings", "", "file holding the rating history, to rate every match")
+	system := flags.String("rating-system", "elo", "how players are rated: elo or trueskill")
+	flags.Parse(args)
+	logOpts.open()
+
+	if *configFile == "" {
+		log.Fatalf("tournament needs a config")
+	}
+	cfg := tournament.Config{
+		Format:  tournament.RoundRobin,
+		Size:    2,
+		Workers: *workers,
+		Match: match.Config{
+			Arena:          "classic",
+			Width:          MaxX + 2,
+			Height:         MaxY + 2,
+			RockFreeRadius: RockFreeArea,
+			RockDensity:    RockDensity,
+			PowerUps:       powerup.Distribution{Counts: make(map[powerup.Kind]int)},
+			Rules:          engine.DefaultRules,
+			MaxTurns:       3000,
+		},
+	}
+	for kind, n := range powerUps {
+		cfg.Match.PowerUps.Counts[kind] = n
+	}
+
+	fd, err := os.Open(*configFile)
+	if err != nil {
+		log.Fatalf("Opening tournament config: %v", err)
+	}
+	err = json.NewDecoder(fd).Decode(&cfg)
+	fd.Close()
+	if err != nil {
+		log.Fatalf("Reading tournament config: %v", err)
+	}
+
+	if cfg.Ratings, err = openRatings(*ratings, *system); err != nil {
+		log.Fatalf("Opening ratings: %v", err)
+	}
+	if cfg.Ratings != nil {
+		defer cfg.Ratings.Close()
+	}
+
+	cfg.MatchLog = logOpts.matchLog
+	logOpts.crashDumps(&cfg.Match)
+	standings, err := tournament.Run(cfg, *out, log)
+	if err != nil {
+		log.Fatalf("Playing tournament: %v", err)
+	}
+	fmt.Print(tournament.Table(standings))
+}
@@ -0,0 +1,96 @@
+package tournament
+
+import (
+	"sort"
+)
+
+// roundRobin lists every group of size entries, by index, in a stable order.
+func roundRobin(n, size int) [][]int {
+	var groups [][]int
+	var pick func(start int, group []int)
+	pick = func(start int, group []int) {
+		if len(group) == size {
+			groups = append(groups, append([]int{}, group...))
+			return
+		}
+		for i := start; i < n; i++ {
+			pick(i+1, append(group, i))
+		}
+	}
+	pick(0, nil)
+	return groups
+}
+
+// swiss groups entries of similar points, avoiding entries that already met
+// when it can. Entries left over get a bye.
+func swiss(points []float64, met map[[2]int]bool, hadBye []bool, size int) (groups [][]int, byes []int) {
+	order := make([]int, len(points))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return points[order[i]] > points[order[j]] })
+
+	// The lowest ranked entries without a bye yet sit out, so everyone else
+	// can play.
+	for len(order)%size != 0 {
+		out := len(order) - 1
+		for i := len(order) - 1; i >= 0; i-- {
+			if !hadBye[order[i]] {
+				out = i
+				break
+			}
+		}
+		byes = append(byes, order[out])
+		order = append(order[:out], order[out+1:]...)
+	}
+
+	paired := make([]bool, len(points))
+	for _, first := range order {
+		if paired[first] {
+			continue
+		}
+		group := []int{first}
+		paired[first] = true
+		// Prefer strangers, then whoever is next in the standings.
+		for _, strangers := range []bool{true, false} {
+			for _, other := range order {
+				if len(group) == size {
+					break
+				}
+				if paired[other] || (strangers && metAny(met, group, other)) {
+					continue
+				}
+				group = append(group, other)
+				paired[other] = true
+			}
+		}
+		groups = append(groups, group)
+	}
+	return groups, byes
+}
+
+func metAny(met map[[2]int]bool, group []int, other int) bool {
+	for _, i := range group {
+		if met[pair(i, other)] {
+			return true
+		}
+	}
+	return false
+}
+
+func pair(i, j int) [2]int {
+	if i > j {
+		i, j = j, i
+	}
+	return [2]int{i, j}
+}
+
+// rotations seats a group in every order obtained by turning it, so each entry
+// starts once from every spawn.
+func rotations(group []int) [][]int {
+	seatings := make([][]int, len(group))
+	for r := range group {
+		seatings[r] = append(append([]int{}, group[r:]...), group[:r]...)
+	}
+	return seatings
+}
@@ -0,0 +1,373 @@
+// Package tournament runs bot competitions: round-robin or Swiss pairings of
+// headless matches, with seats rotated so no bot keeps the best spawn.
+// Matches are saved as they finish, so an interrupted tournament picks up
+// where it stopped.
+package tournament
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/aybabtme/bomberman/config"
+	"github.com/aybabtme/bomberman/logger"
+	"github.com/aybabtme/bomberman/match"
+	"github.com/aybabtme/bomberman/rating"
+	"hash/fnv"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"text/tabwriter"
+)
+
+// Formats of tournaments.
+const (
+	RoundRobin = "round-robin"
+	Swiss      = "swiss"
+)
+
+// Config describes a tournament.
+type Config struct {
+	// Format is either "round-robin" or "swiss".
+	Format string `json:"format"`
+	// Size is how many entries play each match, 2 or 4.
+	Size int `json:"size"`
+	// Rounds of a Swiss tournament. Zero plays enough rounds to tell
+	// everyone apart.
+	Rounds int `json:"rounds,omitempty"`
+	// Repeat plays every seating that many times.
+	Repeat int   `json:"repeat,omitempty"`
+	Seed   int64 `json:"seed"`
+	// Workers play matches in parallel.
+	Workers int `json:"workers,omitempty"`
+
+	Match   match.Config   `json:"match"`
+	Entries []config.Entry `json:"entries"`
+
+	// Ratings, if set, rates every match as it's scored.
+	Ratings *rating.Store `json:"-"`
+	// MatchLog, if set, gives the logger of every match played, and what
+	// to call once it's over.
+	MatchLog func(id string) (*logger.Logger, func()) `json:"-"`
+}
+
+// Validate checks that the tournament can be played.
+func (c *Config) Validate() error {
+	if c.Format != RoundRobin && c.Format != Swiss {
+		return fmt.Errorf("unknown format %q", c.Format)
+	}
+	if c.Size != 2 && c.Size != 4 {
+		return fmt.Errorf("matches are played by 2 or 4 entries, not %d", c.Size)
+	}
+	if len(c.Entries) < c.Size {
+		return fmt.Errorf("need at least %d entries, got %d", c.Size, len(c.Entries))
+	}
+	if c.Match.MaxTurns <= 0 {
+		return fmt.Errorf("matches need a positive max_turns")
+	}
+	names := make(map[string]bool)
+	for i, e := range c.Entries {
+		if e.Name == "" || names[e.Name] {
+			return fmt.Errorf("entry %d: missing or duplicate name %q", i, e.Name)
+		}
+		names[e.Name] = true
+		if !match.Playable(e.Kind) || e.Kind == match.External {
+			return fmt.Errorf("entry %q: kind %q can't play headless", e.Name, e.Kind)
+		}
+	}
+	return nil
+}
+
+// Replay is what's saved of a match: enough to play it again, and how it
+// ended.
+type Replay struct {
+	ID    string `json:"id"`
+	Round int    `json:"round"`
+	// Seats lists the entries from the first spawn on.
+	Seats  []config.Entry `json:"seats"`
+	Seed   int64          `json:"seed"`
+	Match  match.Config   `json:"match"`
+	Result *match.Result  `json:"result"`
+}
+
+// Standing is how an entry is doing. Entries get a point for every opponent
+// they outlast in a match, and half a point for opponents dying on the same
+// turn as them.
+type Standing struct {
+	Name   string  `json:"name"`
+	Points float64 `json:"points"`
+	Played int     `json:"played"`
+	Wins   int     `json:"wins"`
+	Draws  int     `json:"draws"`
+	Byes   int     `json:"byes"`
+}
+
+type tournament struct {
+	cfg Config
+	dir string
+	// id tells tournaments apart in the ratings.
+	id   string
+	log  *logger.Logger
+	byID map[string]int
+
+	standings []Standing
+	met       map[[2]int]bool
+}
+
+// Run plays a tournament, saving matches and standings in dir. Matches
+// already saved there aren't played again.
+func Run(cfg Config, dir string, log *logger.Logger) ([]Standing, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if cfg.Repeat < 1 {
+		cfg.Repeat = 1
+	}
+	if cfg.Workers < 1 {
+		cfg.Workers = 1
+	}
+	if cfg.Format == Swiss && cfg.Rounds < 1 {
+		cfg.Rounds = int(math.Ceil(math.Log2(float64(len(cfg.Entries)))))
+	}
+	// Rounds of a round-robin all happen at once.
+	if cfg.Format == RoundRobin {
+		cfg.Rounds = 1
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "matches"), 0755); err != nil {
+		return nil, err
+	}
+
+	id, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	t := &tournament{
+		cfg:  cfg,
+		dir:  dir,
+		id:   id,
+		log:  log,
+		byID: make(map[string]int),
+		met:  make(map[[2]int]bool),
+	}
+	for i, e := range cfg.Entries {
+		t.standings = append(t.standings, Standing{Name: e.Name})
+		t.byID[e.Name] = i
+	}
+
+	for round := 1; round <= cfg.Rounds; round++ {
+		var groups [][]int
+		if cfg.Format == RoundRobin {
+			groups = roundRobin(len(cfg.Entries), cfg.Size)
+		} else {
+			var byes []int
+			groups, byes = swiss(t.points(), t.met, t.hadBye(), cfg.Size)
+			for _, i := range byes {
+				// A bye is worth a draw against everyone.
+				t.standings[i].Byes++
+				t.standings[i].Points += float64(cfg.Size-1) / 2
+			}
+		}
+
+		replays, err := t.play(round, groups)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range replays {
+			if err := t.score(r); err != nil {
+				return nil, err
+			}
+		}
+		if err := t.save(); err != nil {
+			return nil, err
+		}
+		log.Infof("Round %d/%d done, %d matches.", round, cfg.Rounds, len(replays))
+	}
+	return t.sorted(), nil
+}
+
+// play plays the matches of a round, or loads them if they were already
+// played.
+func (t *tournament) play(round int, groups [][]int) ([]*Replay, error) {
+	var replays []*Replay
+	for g, group := range groups {
+		for s, seating := range rotations(group) {
+			for k := 0; k < t.cfg.Repeat; k++ {
+				r := &Replay{
+					ID:    fmt.Sprintf("r%02d-g%03d-s%d-%d", round, g, s, k),
+					Round: round,
+					Match: t.cfg.Match,
+				}
+				r.Seed = t.cfg.Seed + seedOf(r.ID)
+				for _, i := range seating {
+					e := t.cfg.Entries[i]
+					// Entries fight on their own.
+					e.Team = ""
+					r.Seats = append(r.Seats, e)
+				}
+				replays = append(replays, r)
+			}
+		}
+	}
+
+	jobs := make(chan *Replay)
+	errs := make(chan error, len(replays))
+	wg := sync.WaitGroup{}
+	for w := 0; w < t.cfg.Workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for r := range jobs {
+				if err := t.playOne(r); err != nil {
+					errs <- fmt.Errorf("match %s: %v", r.ID, err)
+				}
+			}
+		}()
+	}
+	for _, r := range replays {
+		jobs <- r
+	}
+	close(jobs)
+	wg.Wait()
+
+	select {
+	case err := <-errs:
+		return nil, err
+	default:
+	}
+	return replays, nil
+}
+
+func (t *tournament) playOne(r *Replay) error {
+	filename := filepath.Join(t.dir, "matches", r.ID+".json")
+	if data, err := ioutil.ReadFile(filename); err == nil {
+		saved := &Replay{}
+		if err := json.Unmarshal(data, saved); err == nil && saved.Result != nil {
+			r.Result = saved.Result
+			return nil
+		}
+		t.log.Warnf("Replaying unreadable match %s.", r.ID)
+	}
+
+	cfg := t.cfg.Match
+	cfg.Record = true
+	log, done := t.log.With("match", r.ID), func() {}
+	if t.cfg.MatchLog != nil {
+		log, done = t.cfg.MatchLog(r.ID)
+	}
+	defer done()
+	res, err := match.Play(cfg, r.Seats, r.Seed, log)
+	if err != nil {
+		return err
+	}
+	r.Result = res
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return writeFile(filename, data)
+}
+
+// score counts a match in the standings, and in the ratings.
+func (t *tournament) score(r *Replay) error {
+	names := make([]string, len(r.Seats))
+	for i, e := range r.Seats {
+		names[i] = e.Name
+	}
+	ranks := r.Result.Ranks(names)
+	for i, name := range names {
+		s := &t.standings[t.byID[name]]
+		s.Played++
+		switch {
+		case r.Result.Winner == name:
+			s.Wins++
+		case r.Result.Draw():
+			s.Draws++
+		}
+		for j, other := range names {
+			switch {
+			case i == j:
+			case ranks[i] < ranks[j]:
+				s.Points++
+			case ranks[i] == ranks[j]:
+				s.Points += 0.5
+			}
+			t.met[pair(t.byID[name], t.byID[other])] = true
+		}
+	}
+
+	if t.cfg.Ratings == nil {
+		return nil
+	}
+	// Matches of resumed tournaments were already rated, and are skipped.
+	_, err := t.cfg.Ratings.Record(rating.Match{
+		ID:      t.id + "/" + r.ID,
+		Players: names,
+		Ranks:   ranks,
+	})
+	return err
+}
+
+func (t *tournament) points() []float64 {
+	points := make([]float64, len(t.standings))
+	for i, s := range t.standings {
+		points[i] = s.Points
+	}
+	return points
+}
+
+func (t *tournament) hadBye() []bool {
+	had := make([]bool, len(t.standings))
+	for i, s := range t.standings {
+		had[i] = s.Byes > 0
+	}
+	return had
+}
+
+func (t *tournament) sorted() []Standing {
+	sorted := append([]Standing{}, t.standings...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Points > sorted[j].Points })
+	return sorted
+}
+
+// save writes the standings as JSON and as a table.
+func (t *tournament) save() error {
+	standings := t.sorted()
+	data, err := json.MarshalIndent(standings, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFile(filepath.Join(t.dir, "standings.json"), data); err != nil {
+		return err
+	}
+	return writeFile(filepath.Join(t.dir, "standings.txt"), []byte(Table(standings)))
+}
+
+// Table formats standings for people.
+func Table(standings []Standing) string {
+	buf := bytes.NewBuffer(nil)
+	tw := tabwriter.NewWriter(buf, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "#\tentry\tpoints\tplayed\twins\tdraws\tbyes")
+	for i, s := range standings {
+		fmt.Fprintf(tw, "%d\t%s\t%.1f\t%d\t%d\t%d\t%d\n", i+1, s.Name, s.Points, s.Played, s.Wins, s.Draws, s.Byes)
+	}
+	tw.Flush()
+	return buf.String()
+}
+
+// writeFile replaces a file at once, so an interruption never leaves half of
+// it behind.
+func writeFile(filename string, data []byte) error {
+	tmp := filename + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filename)
+}
+
+func seedOf(id string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(id))
+	return int64(h.Sum64() >> 1)
+}
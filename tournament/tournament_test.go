@@ -0,0 +1,82 @@
+package tournament
+
+import (
+	"github.com/aybabtme/bomberman/config"
+	"github.com/aybabtme/bomberman/engine"
+	"github.com/aybabtme/bomberman/logger"
+	"github.com/aybabtme/bomberman/match"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestRoundRobinGroups(t *testing.T) {
+	if got := len(roundRobin(5, 2)); got != 10 {
+		t.Errorf("want 10 pairs of 5 entries, got %d", got)
+	}
+	if got := len(roundRobin(6, 4)); got != 15 {
+		t.Errorf("want 15 groups of 4 among 6 entries, got %d", got)
+	}
+}
+
+func TestSwissAvoidsRematches(t *testing.T) {
+	points := []float64{3, 2, 2, 1, 0}
+	met := map[[2]int]bool{pair(0, 1): true}
+	groups, byes := swiss(points, met, make([]bool, 5), 2)
+	if want := []int{4}; !reflect.DeepEqual(byes, want) {
+		t.Errorf("want bye for %v, got %v", want, byes)
+	}
+	if want := [][]int{{0, 2}, {1, 3}}; !reflect.DeepEqual(groups, want) {
+		t.Errorf("want groups %v, got %v", want, groups)
+	}
+}
+
+func TestRunResumes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tournament")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cfg := Config{
+		Format: Swiss,
+		Size:   2,
+		Seed:   1,
+		Match: match.Config{
+			Arena:          "classic",
+			Width:          13,
+			Height:         11,
+			RockFreeRadius: 1,
+			RockDensity:    0.5,
+			Rules:          engine.DefaultRules,
+			MaxTurns:       200,
+		},
+		Entries: []config.Entry{
+			{Name: "r1", Kind: "random"},
+			{Name: "r2", Kind: "random"},
+			{Name: "w1", Kind: "wandering"},
+		},
+	}
+	log := logger.NewWriter("", ioutil.Discard, logger.Error)
+	first, err := Run(cfg, dir, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	played := 0
+	for _, s := range first {
+		played += s.Played
+	}
+	// 2 rounds, 1 match of 2 entries in each, played from both seatings.
+	if played != 8 {
+		t.Errorf("want 8 seats played, got %d: %+v", played, first)
+	}
+
+	again, err := Run(cfg, dir, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(first, again) {
+		t.Errorf("want the saved tournament, got\n%+v\nthen\n%+v", first, again)
+	}
+}